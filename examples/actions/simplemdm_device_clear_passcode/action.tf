@@ -0,0 +1,10 @@
+data "simplemdm_device" "ipad" {
+  udid = "00008030-000A1B2C3D4E5F6A"
+}
+
+# Run with terraform apply -invoke=action.simplemdm_device_clear_passcode.ipad
+action "simplemdm_device_clear_passcode" "ipad" {
+  config {
+    device_id = data.simplemdm_device.ipad.id
+  }
+}
@@ -0,0 +1,13 @@
+data "simplemdm_device" "lost" {
+  serial_number = "C02ABC123XYZ"
+}
+
+# Run with terraform apply -invoke=action.simplemdm_device_lock.lost
+action "simplemdm_device_lock" "lost" {
+  config {
+    device_id    = data.simplemdm_device.lost.id
+    message      = "This Mac is lost, please call IT."
+    phone_number = "+1 555 0100"
+    pin          = "123456"
+  }
+}
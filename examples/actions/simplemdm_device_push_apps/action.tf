@@ -0,0 +1,22 @@
+data "simplemdm_device" "laptop" {
+  serial_number = "C02ABC123XYZ"
+}
+
+action "simplemdm_device_push_apps" "laptop" {
+  config {
+    device_id = data.simplemdm_device.laptop.id
+  }
+}
+
+# Installs the apps of the new assignment groups right away.
+resource "simplemdm_device" "laptop" {
+  name         = "Design laptop"
+  devicegroups = [simplemdm_assignmentgroup.design.id]
+
+  lifecycle {
+    action_trigger {
+      events  = [after_create, after_update]
+      actions = [action.simplemdm_device_push_apps.laptop]
+    }
+  }
+}
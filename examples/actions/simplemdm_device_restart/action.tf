@@ -0,0 +1,22 @@
+data "simplemdm_device" "kiosk" {
+  serial_number = "C02ABC123XYZ"
+}
+
+action "simplemdm_device_restart" "kiosk" {
+  config {
+    device_id = data.simplemdm_device.kiosk.id
+  }
+}
+
+# Restarts the kiosk after its assignment group changed.
+resource "simplemdm_device" "kiosk" {
+  name         = "Lobby kiosk"
+  devicegroups = [simplemdm_assignmentgroup.kiosks.id]
+
+  lifecycle {
+    action_trigger {
+      events  = [after_update]
+      actions = [action.simplemdm_device_restart.kiosk]
+    }
+  }
+}
@@ -0,0 +1,6 @@
+# Run with terraform apply -invoke=action.simplemdm_device_shutdown.lab
+action "simplemdm_device_shutdown" "lab" {
+  config {
+    device_id = "138262"
+  }
+}
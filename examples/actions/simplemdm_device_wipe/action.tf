@@ -0,0 +1,12 @@
+data "simplemdm_device" "returned" {
+  serial_number = "C02ABC123XYZ"
+}
+
+# Run with terraform apply -invoke=action.simplemdm_device_wipe.returned
+action "simplemdm_device_wipe" "returned" {
+  config {
+    device_id             = data.simplemdm_device.returned.id
+    obliteration_behavior = "DoNotObliterate"
+    preserve_data_plan    = false
+  }
+}
@@ -1,3 +1,10 @@
 data "simplemdm_device" "mydevice" {
   id = "138262"
-}
\ No newline at end of file
+}
+output "mydevice_os_version" {
+  value = data.simplemdm_device.mydevice.os_version
+}
+
+data "simplemdm_device" "byserial" {
+  serial_number = "C02ABC123XYZ"
+}
@@ -0,0 +1,10 @@
+data "simplemdm_devices" "sonoma_macbooks" {
+  model      = "MacBook Pro"
+  os_version = "14"
+  status     = "enrolled"
+}
+
+resource "simplemdm_assignmentgroup" "sonoma_macbooks" {
+  name    = "Sonoma MacBooks"
+  devices = data.simplemdm_devices.sonoma_macbooks.ids
+}
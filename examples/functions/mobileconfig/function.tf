@@ -0,0 +1,21 @@
+resource "simplemdm_customprofile" "wifi" {
+  name         = "Office Wi-Fi"
+  mobileconfig = provider::simplemdm::mobileconfig({
+    PayloadIdentifier  = "com.example.wifi"
+    PayloadDisplayName = "Office Wi-Fi"
+    PayloadContent = [{
+      PayloadType    = "com.apple.wifi.managed"
+      SSID_STR       = "Office"
+      EncryptionType = "WPA2"
+      AutoJoin       = true
+    }, {
+      PayloadType                = "com.apple.security.root"
+      PayloadContent             = { "$data" = filebase64("root-ca.cer") }
+      PayloadCertificateFileName = "root-ca.cer"
+    }]
+  })
+  userscope              = false
+  attributesupport       = false
+  escapeattributes       = false
+  reinstallafterosupdate = false
+}
@@ -0,0 +1,19 @@
+locals {
+  # Signed or binary profiles have to be read with filebase64().
+  vpn = provider::simplemdm::mobileconfig_decode(filebase64("vpn.mobileconfig"))
+}
+
+output "vpn_signers" {
+  value = local.vpn.signers[*].subject
+}
+
+resource "simplemdm_customprofile" "vpn" {
+  name = "VPN"
+  mobileconfig = provider::simplemdm::mobileconfig(merge(local.vpn.profile, {
+    PayloadDisplayName = "VPN (managed by Terraform)"
+  }))
+  userscope              = false
+  attributesupport       = false
+  escapeattributes       = false
+  reinstallafterosupdate = false
+}
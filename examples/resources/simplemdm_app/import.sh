@@ -1,2 +1,5 @@
 # App can be imported by specifying the app ID.
-terraform import simplemdm_app.example 123456
\ No newline at end of file
+terraform import simplemdm_app.example 123456
+
+# or its bundle identifier, which keeps bundle_id instead of app_store_id.
+terraform import simplemdm_app.example bundle_id:com.myCompany.MyApp1
\ No newline at end of file
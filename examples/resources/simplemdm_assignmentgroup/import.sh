@@ -1,2 +1,5 @@
 # Assignment group can be imported by specifying the Assigntment group ID.
-terraform import simplemdm_assigntmentgroup.example 123456
\ No newline at end of file
+terraform import simplemdm_assigntmentgroup.example 123456
+
+# or its name, the import fails when several groups have the name.
+terraform import simplemdm_assigntmentgroup.example "name:Engineering Macs"
\ No newline at end of file
@@ -1,2 +1,5 @@
 # Custom profile can be imported by specifying the custom profile ID.
-terraform import simplemdm_customprofile.example 123456
\ No newline at end of file
+terraform import simplemdm_customprofile.example 123456
+
+# or its name, the import fails when several custom profiles have the name.
+terraform import simplemdm_customprofile.example "name:Wi-Fi Corp"
\ No newline at end of file
@@ -1,2 +1,5 @@
 # Device can be imported by specifying the device ID.
-terraform import simplemdm_device.example 123456
\ No newline at end of file
+terraform import simplemdm_device.example 123456
+
+# or its serial number.
+terraform import simplemdm_device.example serial:C02XXXXXXXXX
\ No newline at end of file
@@ -1,2 +1,8 @@
-# Script Jobs can be imported by specifying the job ID.
-terraform import simplemdm_scriptjob.example  123456
\ No newline at end of file
+# Script Jobs can be imported by specifying the job ID. The script is found by
+# the name and content the job ran, jobs for assignment groups are imported
+# with the devices the groups had when the job was created.
+terraform import simplemdm_scriptjob.example  123456
+
+# Jobs whose script was edited or deleted since are imported with the script
+# ID after the job ID, or without script_id for the configuration to set it.
+terraform import simplemdm_scriptjob.example  123456:5727
\ No newline at end of file
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/DavidKrau/terraform-provider-simplemdm/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
@@ -29,6 +31,11 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		export(os.Args[2:])
+		return
+	}
+
 	var debug bool
 
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
@@ -50,3 +57,21 @@ func main() {
 		log.Fatal(err.Error())
 	}
 }
+
+// export writes the configuration of a whole SimpleMDM account, for bringing
+// an existing account under Terraform management. It uses the credentials
+// the provider finds without configuration, like SIMPLEMDM_APIKEY.
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory to write the .tf files and the profile, declaration and script files to")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export [-dir directory]\n\n", os.Args[0])
+		fmt.Fprintln(flags.Output(), "Writes resource and import blocks for every object of the SimpleMDM account.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if err := provider.Export(context.Background(), version, *dir); err != nil {
+		log.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// apiError is an error response of the SimpleMDM API.
+type apiError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Body is the raw response body, SimpleMDM sends JSON with an errors
+	// list for most failures.
+	Body string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// NotFound reports whether the object the call referred to doesn't exist.
+func (e *apiError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// Retryable reports whether the same call may succeed when repeated later.
+func (e *apiError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Messages returns the error titles from the response body, or the body
+// itself if it isn't in the SimpleMDM error format.
+func (e *apiError) Messages() []string {
+	var body struct {
+		Errors []struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	messages := []string{}
+	if err := json.Unmarshal([]byte(e.Body), &body); err == nil {
+		for _, apiErr := range body.Errors {
+			message := apiErr.Title
+			if apiErr.Detail != "" {
+				message = strings.TrimSpace(message + " " + apiErr.Detail)
+			}
+			if message != "" {
+				messages = append(messages, message)
+			}
+		}
+	}
+	if len(messages) == 0 && strings.TrimSpace(e.Body) != "" {
+		messages = append(messages, strings.TrimSpace(e.Body))
+	}
+	return messages
+}
+
+// statusPattern matches the error the SimpleMDM client returns for non 2xx
+// responses.
+var statusPattern = regexp.MustCompile(`(?s)status: (\d{3}), body: (.*)`)
+
+// asAPIError extracts the API error from an error returned by the SimpleMDM
+// client. It returns false for errors which didn't come from an API
+// response, such as network failures.
+func asAPIError(err error) (*apiError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var typed *apiError
+	if errors.As(err, &typed) {
+		return typed, true
+	}
+
+	match := statusPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil, false
+	}
+	statusCode, _ := strconv.Atoi(match[1])
+	return &apiError{StatusCode: statusCode, Body: match[2]}, true
+}
+
+// isNotFound reports whether err is the API telling that the object doesn't
+// exist, resources remove such objects from the state.
+func isNotFound(err error) bool {
+	apiErr, ok := asAPIError(err)
+	return ok && apiErr.NotFound()
+}
+
+// apiErrorDiagnostic turns an error returned by the SimpleMDM client into a
+// diagnostic. Authentication, validation and throttling failures get their
+// own summary and a hint on how to resolve them, other errors keep the
+// summary of the caller.
+func apiErrorDiagnostic(summary, detail string, err error) diag.Diagnostic {
+	if detail != "" {
+		detail += ": "
+	}
+
+	detail += apiErrorMessage(err)
+
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return diag.NewErrorDiagnostic(summary, detail)
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+		return diag.NewErrorDiagnostic(
+			"SimpleMDM Authentication Failed",
+			detail+"\n\nThe API key was rejected or is missing the permission for this call. "+
+				"Check the apikey provider attribute or the SIMPLEMDM_APIKEY environment variable and the permissions of the key in SimpleMDM.",
+		)
+	case apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusUnprocessableEntity:
+		return diag.NewErrorDiagnostic(
+			"SimpleMDM Rejected the Request",
+			summary+". "+detail+"\n\nSimpleMDM didn't accept the values sent, check the configuration against the message above.",
+		)
+	case apiErr.StatusCode == http.StatusNotFound:
+		return diag.NewErrorDiagnostic(
+			"SimpleMDM Object Not Found",
+			summary+". "+detail+"\n\nThe object doesn't exist or was deleted outside of Terraform.",
+		)
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return diag.NewErrorDiagnostic(
+			"SimpleMDM API Rate Limit Exceeded",
+			summary+". "+detail+"\n\nThe call was still throttled after all retries. "+
+				"Increase max_retries or retry_max_wait in the provider configuration, or run Terraform with lower parallelism.",
+		)
+	case apiErr.Retryable():
+		return diag.NewErrorDiagnostic(
+			summary,
+			detail+"\n\nSimpleMDM reported a temporary server error, running Terraform again is likely to succeed.",
+		)
+	}
+	return diag.NewErrorDiagnostic(summary, detail)
+}
+
+// apiErrorMessage describes an error returned by the SimpleMDM client in one
+// line, with the status code and the messages of the API if it has them.
+func apiErrorMessage(err error) string {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return err.Error()
+	}
+	message := fmt.Sprintf("SimpleMDM API returned HTTP %d", apiErr.StatusCode)
+	if messages := apiErr.Messages(); len(messages) > 0 {
+		message += ": " + strings.Join(messages, "; ")
+	}
+	return message
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAsAPIError(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		expectOK     bool
+		expectStatus int
+		expectBody   string
+	}{
+		"client error": {
+			err:          fmt.Errorf("status: %d, body: %s", 404, `{"errors":[{"title":"object not found"}]}`),
+			expectOK:     true,
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"errors":[{"title":"object not found"}]}`,
+		},
+		"wrapped typed error": {
+			err:          fmt.Errorf("assigning profile: %w", &apiError{StatusCode: 429, Body: "slow down"}),
+			expectOK:     true,
+			expectStatus: http.StatusTooManyRequests,
+			expectBody:   "slow down",
+		},
+		"network error": {
+			err:      errors.New("dial tcp: connection refused"),
+			expectOK: false,
+		},
+		"id containing 404 is not a status": {
+			err:      errors.New("could not parse device 14041 404"),
+			expectOK: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			apiErr, ok := asAPIError(test.err)
+			if ok != test.expectOK {
+				t.Fatalf("expected ok %t, got %t", test.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if apiErr.StatusCode != test.expectStatus || apiErr.Body != test.expectBody {
+				t.Errorf("expected %d %q, got %d %q", test.expectStatus, test.expectBody, apiErr.StatusCode, apiErr.Body)
+			}
+		})
+	}
+}
+
+func TestAPIErrorRetryable(t *testing.T) {
+	for status, expect := range map[int]bool{400: false, 401: false, 404: false, 422: false, 429: true, 500: true, 502: true, 503: true} {
+		if got := (&apiError{StatusCode: status}).Retryable(); got != expect {
+			t.Errorf("status %d: expected retryable %t, got %t", status, expect, got)
+		}
+	}
+}
+
+func TestAPIErrorDiagnostic(t *testing.T) {
+	tests := map[string]struct {
+		err           error
+		expectSummary string
+		expectDetail  string
+	}{
+		"unauthorized": {
+			err:           &apiError{StatusCode: 401, Body: `{"errors":[{"title":"invalid api key"}]}`},
+			expectSummary: "SimpleMDM Authentication Failed",
+			expectDetail:  "HTTP 401: invalid api key",
+		},
+		"validation": {
+			err:           &apiError{StatusCode: 422, Body: `{"errors":[{"title":"name has already been taken"}]}`},
+			expectSummary: "SimpleMDM Rejected the Request",
+			expectDetail:  "name has already been taken",
+		},
+		"throttled": {
+			err:           &apiError{StatusCode: 429, Body: ""},
+			expectSummary: "SimpleMDM API Rate Limit Exceeded",
+			expectDetail:  "max_retries",
+		},
+		"server error": {
+			err:           &apiError{StatusCode: 502, Body: "Bad Gateway"},
+			expectSummary: "Error creating app",
+			expectDetail:  "temporary server error",
+		},
+		"network error": {
+			err:           errors.New("connection refused"),
+			expectSummary: "Error creating app",
+			expectDetail:  "Could not create app: connection refused",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diagnostic := apiErrorDiagnostic("Error creating app", "Could not create app", test.err)
+			if diagnostic.Summary() != test.expectSummary {
+				t.Errorf("expected summary %q, got %q", test.expectSummary, diagnostic.Summary())
+			}
+			if !strings.Contains(diagnostic.Detail(), test.expectDetail) {
+				t.Errorf("expected detail to contain %q, got %q", test.expectDetail, diagnostic.Detail())
+			}
+		})
+	}
+}
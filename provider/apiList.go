@@ -0,0 +1,236 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// apiListPageSize is the largest page SimpleMDM list endpoints return.
+const apiListPageSize = 100
+
+// apiListPage is one page of a SimpleMDM list endpoint.
+type apiListPage struct {
+	Data    []json.RawMessage `json:"data"`
+	HasMore bool              `json:"has_more"`
+}
+
+// apiAssignmentGroup is an assignment group as list responses return it.
+type apiAssignmentGroup struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		Name     string `json:"name"`
+		Priority int    `json:"priority"`
+	} `json:"attributes"`
+}
+
+// apiDevice is a device as list responses return it.
+type apiDevice struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		Name         string `json:"name"`
+		DeviceName   string `json:"device_name"`
+		SerialNumber string `json:"serial_number"`
+	} `json:"attributes"`
+}
+
+// apiDeviceInventory is a device with the inventory SimpleMDM collected from
+// it, as both the list and the single device responses return it. Values
+// SimpleMDM doesn't know for a device are null.
+type apiDeviceInventory struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		Name                    string          `json:"name"`
+		DeviceName              *string         `json:"device_name"`
+		SerialNumber            *string         `json:"serial_number"`
+		UniqueIdentifier        *string         `json:"unique_identifier"`
+		IMEI                    *string         `json:"imei"`
+		Model                   *string         `json:"model"`
+		ModelName               *string         `json:"model_name"`
+		ProductName             *string         `json:"product_name"`
+		OSVersion               *string         `json:"os_version"`
+		BuildVersion            *string         `json:"build_version"`
+		Status                  *string         `json:"status"`
+		EnrolledAt              *string         `json:"enrolled_at"`
+		LastSeenAt              *string         `json:"last_seen_at"`
+		IsDEPEnrollment         *bool           `json:"is_dep_enrollment"`
+		IsSupervised            *bool           `json:"is_supervised"`
+		BatteryLevel            json.RawMessage `json:"battery_level"`
+		FileVaultEnabled        *bool           `json:"filevault_enabled"`
+		ActivationLockEnabled   *bool           `json:"is_activation_lock_enabled"`
+		DeviceCapacity          *float64        `json:"device_capacity"`
+		AvailableDeviceCapacity *float64        `json:"available_device_capacity"`
+		WiFiMAC                 *string         `json:"wifi_mac"`
+	} `json:"attributes"`
+	Relationships struct {
+		DeviceGroup struct {
+			Data *struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		} `json:"device_group"`
+		Groups struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		} `json:"groups"`
+		CustomAttributeValues struct {
+			Data []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Value string `json:"value"`
+				} `json:"attributes"`
+			} `json:"data"`
+		} `json:"custom_attribute_values"`
+	} `json:"relationships"`
+}
+
+// apiProfile is a profile, custom profile or custom declaration as list
+// responses return it.
+type apiProfile struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+	Relationships struct {
+		// Devices are the devices the profile is directly assigned to.
+		Devices struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		} `json:"devices"`
+	} `json:"relationships"`
+}
+
+// apiApp is an app as list responses return it.
+type apiApp struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		BundleID string `json:"bundle_identifier"`
+	} `json:"attributes"`
+}
+
+// apiScript is a script as list responses return it.
+type apiScript struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		Name    string `json:"name"`
+		Content string `json:"content"`
+	} `json:"attributes"`
+}
+
+// apiScriptJob is a script job with the script and the devices it runs on.
+type apiScriptJob struct {
+	ID         int `json:"id"`
+	Attributes struct {
+		ScriptName string `json:"script_name"`
+		Content    string `json:"content"`
+	} `json:"attributes"`
+	Relationships struct {
+		Device struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		} `json:"device"`
+	} `json:"relationships"`
+}
+
+// listAll fetches every object of a SimpleMDM list endpoint the API client
+// has no method for, such as assignment_groups. The requests go through the
+// HTTP client of the API client, so they are cached, retried and logged like
+// all other calls.
+func listAll[T any](ctx context.Context, c *simplemdmClient, endpoint string, query url.Values) ([]T, error) {
+	var objects []T
+	after := ""
+	for {
+		pageQuery := url.Values{}
+		for key, values := range query {
+			pageQuery[key] = values
+		}
+		pageQuery.Set("limit", strconv.Itoa(apiListPageSize))
+		if after != "" {
+			pageQuery.Set("starting_after", after)
+		}
+
+		var page apiListPage
+		if err := c.apiGet(ctx, endpoint, pageQuery, &page); err != nil {
+			return nil, err
+		}
+		for _, raw := range page.Data {
+			var object T
+			if err := json.Unmarshal(raw, &object); err != nil {
+				return nil, fmt.Errorf("decoding %s: %w", endpoint, err)
+			}
+			objects = append(objects, object)
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			return objects, nil
+		}
+
+		var last struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(page.Data[len(page.Data)-1], &last); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", endpoint, err)
+		}
+		after = strconv.Itoa(last.ID)
+	}
+}
+
+// apiGet fetches a SimpleMDM API endpoint and decodes the JSON response into
+// out. Responses other than 2xx are returned as *apiError.
+func (c *simplemdmClient) apiGet(ctx context.Context, endpoint string, query url.Values, out any) error {
+	target := url.URL{Scheme: "https", Host: c.HostName, Path: "/api/v1/" + endpoint, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.apiKey, "")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return json.Unmarshal(body, out)
+}
+
+// apiPost sends form to a SimpleMDM API endpoint, for commands whose response
+// carries nothing of interest. Responses other than 2xx are returned as
+// *apiError.
+func (c *simplemdmClient) apiPost(ctx context.Context, endpoint string, form url.Values) error {
+	target := url.URL{Scheme: "https", Host: c.HostName, Path: "/api/v1/" + endpoint}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.apiKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -18,9 +17,10 @@ import (
 )
 
 var (
-	_ resource.Resource                = &appResource{}
-	_ resource.ResourceWithConfigure   = &appResource{}
-	_ resource.ResourceWithImportState = &appResource{}
+	_ resource.Resource                 = &appResource{}
+	_ resource.ResourceWithConfigure    = &appResource{}
+	_ resource.ResourceWithImportState  = &appResource{}
+	_ resource.ResourceWithUpgradeState = &appResource{}
 )
 
 // appResourceModel maps the resource schema data.
@@ -38,7 +38,7 @@ func AppResource() resource.Resource {
 
 // appResource is the resource implementation.
 type appResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -47,7 +47,7 @@ func (r *appResource) Configure(_ context.Context, req resource.ConfigureRequest
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -58,6 +58,7 @@ func (r *appResource) Metadata(_ context.Context, req resource.MetadataRequest,
 // Schema defines the schema for the resource.
 func (r *appResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "App resource can be used to manage Apps.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -75,8 +76,8 @@ func (r *appResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 				Computed:    true,
 				Description: "Required. The Apple App Store ID of the app to be added. Example: 1090161858.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
 					stringplanmodifier.UseStateForUnknown(),
+					requiresReplaceOfAppIdentifier(),
 				},
 				Validators: []validator.String{
 					stringvalidator.ExactlyOneOf(
@@ -90,8 +91,8 @@ func (r *appResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 				Computed:    true,
 				Description: "Required. The bundle identifier of the Apple App Store app to be added. Example: com.myCompany.MyApp1",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
 					stringplanmodifier.UseStateForUnknown(),
+					requiresReplaceOfAppIdentifier(),
 				},
 				Validators: []validator.String{
 					stringvalidator.ExactlyOneOf(
@@ -106,16 +107,30 @@ func (r *appResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 				Description: "Optional. Deploy the app to associated devices immediately after the app has been uploaded and processed. Possible values are none, outdated or all. Defaults to none.",
 				Default:     stringdefault.StaticString("none"),
 				Validators: []validator.String{
-					stringvalidator.OneOf("outdated", "all"),
+					stringvalidator.OneOf("none", "outdated", "all"),
 				},
 			},
 		},
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *appResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
+// ImportState imports an app by its ID or by bundle_id:<bundle identifier>.
+// Apps imported by bundle identifier keep bundle_id instead of app_store_id.
 func (r *appResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importByLookup(ctx, req, resp, "app", map[string]importLookup{
+		"bundle_id": appsWithBundleID(r.client),
+	})
+	if bundleID, found := strings.CutPrefix(req.ID, "bundle_id:"); found && !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("bundle_id"), bundleID)...)
+	}
 }
 
 // Create a new resource
@@ -145,10 +160,11 @@ func (r *appResource) Create(ctx context.Context, req resource.CreateRequest, re
 		name,
 	)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating app",
-			"Could not create app, unexpected error: "+err.Error(),
-		)
+			"Could not create app",
+			err,
+		))
 		return
 	}
 
@@ -179,11 +195,12 @@ func (r *appResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 	// Delete existing app
 	err := r.client.AppDelete(state.ID.ValueString())
 
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM app",
-			"Could not delete app, unexpected error: "+err.Error(),
-		)
+			"Could not delete app",
+			err,
+		))
 		return
 	}
 }
@@ -198,26 +215,36 @@ func (r *appResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 
 	app, err := r.client.AppGet(state.ID.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM App",
-			"Could not read SimpleMDM App "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM App "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
 	state.ID = types.StringValue(strconv.Itoa(app.Data.ID))
 	state.Name = types.StringValue(app.Data.Attributes.Name)
 
-	if app.Data.Attributes.AppStoreId != 0 {
+	// Apps are configured with either app_store_id or bundle_id, imported
+	// apps only read back the one they were imported by, the App Store ID
+	// for imports by ID.
+	imported := state.AppStoreId.IsNull() && state.BundleId.IsNull()
+	importedByBundleID := state.AppStoreId.IsNull() && !state.BundleId.IsNull()
+	if app.Data.Attributes.AppStoreId != 0 && !importedByBundleID {
 		state.AppStoreId = types.StringValue(strconv.Itoa(app.Data.Attributes.AppStoreId))
 	}
-	if app.Data.Attributes.BundleId != "" {
+	if app.Data.Attributes.BundleId != "" && !(imported && app.Data.Attributes.AppStoreId != 0) {
 		state.BundleId = types.StringValue(app.Data.Attributes.BundleId)
 	}
+	// SimpleMDM doesn't store deploy_to, imported apps get the default.
+	if state.DeployTo.IsNull() {
+		state.DeployTo = types.StringValue("none")
+	}
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -243,13 +270,27 @@ func (r *appResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		plan.DeployTo.ValueString(),
 	)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating app",
-			"Failed to update app: "+err.Error(),
-		)
+			"Failed to update app",
+			err,
+		))
 		return
 	}
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
+
+// requiresReplaceOfAppIdentifier replaces the app when its app_store_id or
+// bundle_id changes. Imported apps have only one of them, setting the other
+// one doesn't replace them.
+func requiresReplaceOfAppIdentifier() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(_ context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = !req.StateValue.IsNull()
+		},
+		"Changing the identifier of the app replaces it, unless the state has none.",
+		"Changing the identifier of the app replaces it, unless the state has none.",
+	)
+}
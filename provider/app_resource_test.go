@@ -1,8 +1,11 @@
 package provider
 
 import (
+	"context"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
 
@@ -26,12 +29,13 @@ func TestAccAppResourceWithAppStoreIdAttr(t *testing.T) {
 					resource.TestCheckResourceAttrSet("simplemdm_app.testapp", "id"),
 				),
 			},
-			// ImportState testing
+			// ImportState testing, imported App Store apps only read back
+			// app_store_id.
 			{
 				ResourceName:            "simplemdm_app.testapp",
 				ImportState:             true,
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"deploy_to"},
+				ImportStateVerifyIgnore: []string{"deploy_to", "bundle_id"},
 			},
 			//Update and Read testing
 			{
@@ -74,12 +78,14 @@ func TestAccAppResourceWithBundleIdAttr(t *testing.T) {
 					resource.TestCheckResourceAttrSet("simplemdm_app.testapp", "id"),
 				),
 			},
-			// ImportState testing
+			// ImportState testing, apps imported by bundle identifier don't
+			// read back app_store_id.
 			{
 				ResourceName:            "simplemdm_app.testapp",
 				ImportState:             true,
+				ImportStateId:           "bundle_id:com.microsoft.Office.Excel",
 				ImportStateVerify:       true,
-				ImportStateVerifyIgnore: []string{"deploy_to"},
+				ImportStateVerifyIgnore: []string{"deploy_to", "app_store_id"},
 			},
 			//Update and Read testing
 			{
@@ -102,3 +108,72 @@ func TestAccAppResourceWithBundleIdAttr(t *testing.T) {
 		},
 	})
 }
+
+func TestAppImportKeepsIdentifier(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	server, schemas := testActionServer(t, fake)
+	ctx := context.Background()
+	objectType := schemas.ResourceSchemas["simplemdm_app"].ValueType().(tftypes.Object)
+
+	importApp := func(importID string) map[string]tftypes.Value {
+		t.Helper()
+		imported, err := server.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{TypeName: "simplemdm_app", ID: importID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testNoDiagnostics(t, imported.Diagnostics)
+		read, err := server.ReadResource(ctx, &tfprotov6.ReadResourceRequest{TypeName: "simplemdm_app", CurrentState: imported.ImportedResources[0].State})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testNoDiagnostics(t, read.Diagnostics)
+		state, err := read.NewState.Unmarshal(objectType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attributes map[string]tftypes.Value
+		if err := state.As(&attributes); err != nil {
+			t.Fatal(err)
+		}
+		return attributes
+	}
+
+	byBundleID := importApp("bundle_id:com.agilebits.onepassword7")
+	if !byBundleID["id"].Equal(tftypes.NewValue(tftypes.String, "553192")) ||
+		!byBundleID["bundle_id"].Equal(tftypes.NewValue(tftypes.String, "com.agilebits.onepassword7")) ||
+		!byBundleID["app_store_id"].IsNull() {
+		t.Errorf("expected the app imported by bundle identifier to keep only bundle_id, got %v", byBundleID)
+	}
+
+	// Configuring the identifier the app wasn't imported by updates it in
+	// place.
+	byID := importApp("553192")
+	if byID["app_store_id"].IsNull() || !byID["bundle_id"].IsNull() {
+		t.Fatalf("expected the app imported by ID to have only app_store_id, got %v", byID)
+	}
+	config := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		config[name] = tftypes.NewValue(attributeType, nil)
+	}
+	config["bundle_id"] = byBundleID["bundle_id"]
+	config["deploy_to"] = byID["deploy_to"]
+	proposed := map[string]tftypes.Value{}
+	for name, value := range byID {
+		proposed[name] = value
+	}
+	proposed["bundle_id"] = config["bundle_id"]
+	planned, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "simplemdm_app",
+		PriorState:       testDynamicValue(t, tftypes.NewValue(objectType, byID)),
+		ProposedNewState: testDynamicValue(t, tftypes.NewValue(objectType, proposed)),
+		Config:           testDynamicValue(t, tftypes.NewValue(objectType, config)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, planned.Diagnostics)
+	if len(planned.RequiresReplace) > 0 {
+		t.Errorf("plan replaces the app because of %v", planned.RequiresReplace)
+	}
+}
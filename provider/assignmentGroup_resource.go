@@ -2,16 +2,20 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -21,9 +25,11 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &assignment_groupResource{}
-	_ resource.ResourceWithConfigure   = &assignment_groupResource{}
-	_ resource.ResourceWithImportState = &assignment_groupResource{}
+	_ resource.Resource                 = &assignment_groupResource{}
+	_ resource.ResourceWithConfigure    = &assignment_groupResource{}
+	_ resource.ResourceWithImportState  = &assignment_groupResource{}
+	_ resource.ResourceWithUpgradeState = &assignment_groupResource{}
+	_ resource.ResourceWithModifyPlan   = &assignment_groupResource{}
 )
 
 // assignment_groupResourceModel maps the resource schema data.
@@ -38,8 +44,9 @@ type assignment_groupResourceModel struct {
 	ProfilesSync     types.Bool   `tfsdk:"profiles_sync"`
 	Devices          types.Set    `tfsdk:"devices"`
 	Attributes       types.Map    `tfsdk:"attributes"`
-	Priority         types.String `tfsdk:"priority"`
+	Priority         types.Int64  `tfsdk:"priority"`
 	AppTrackLocation types.Bool   `tfsdk:"app_track_location"`
+	OnPartialFailure types.String `tfsdk:"on_partial_failure"`
 }
 
 type appModel struct {
@@ -55,7 +62,7 @@ func AssignmentGroupResource() resource.Resource {
 
 // assignment_groupResource is the resource implementation.
 type assignment_groupResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -64,7 +71,7 @@ func (r *assignment_groupResource) Configure(_ context.Context, req resource.Con
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -75,6 +82,7 @@ func (r *assignment_groupResource) Metadata(_ context.Context, req resource.Meta
 // Schema defines the schema for the resource.
 func (r *assignment_groupResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     2,
 		Description: "Assignment Group resource is used to manage group, you can assign App(s), Profile(s), Custom Profile(s), Custom Declaration(s), Device(s) and set addition details regarding Group. In case you dont want to manage device/app assignments use lifecycle. Currently App assignment will always show diff in configuration as API is not providing all needed data (request for API change was already submitted).",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -141,13 +149,13 @@ func (r *assignment_groupResource) Schema(_ context.Context, _ resource.SchemaRe
 				Optional:    true,
 				Computed:    true,
 				Default:     booldefault.StaticBool(true),
-				Description: "Optional. Updates associated apps on associated devices. A munki catalog refresh or MDM install command will be sent to all associated devices. Defaults to true",
+				Description: "Optional. Updates associated apps on associated devices. A munki catalog refresh or MDM install command will be sent to all associated devices. Defaults to true. SimpleMDM doesn't store it, imported groups get the default.",
 			},
 			"apps_push": schema.BoolAttribute{
 				Optional:    true,
 				Computed:    true,
 				Default:     booldefault.StaticBool(true),
-				Description: "Optional. Installs associated apps to associated devices. A munki catalog refresh or MDM install command will be sent to all associated devices. Defaults to true.",
+				Description: "Optional. Installs associated apps to associated devices. A munki catalog refresh or MDM install command will be sent to all associated devices. Defaults to true. SimpleMDM doesn't store it, imported groups get the default.",
 			},
 			"profiles": schema.SetAttribute{
 				ElementType: types.StringType,
@@ -158,7 +166,7 @@ func (r *assignment_groupResource) Schema(_ context.Context, _ resource.SchemaRe
 				Optional:    true,
 				Computed:    true,
 				Default:     booldefault.StaticBool(true),
-				Description: "Optional. Set true if you would like to send Sync Profiles command after Group creation or changes. Defaults to true.",
+				Description: "Optional. Set true if you would like to send Sync Profiles command after Group creation or changes. Defaults to true. SimpleMDM doesn't store it, imported groups get the default.",
 			},
 			"devices": schema.SetAttribute{
 				ElementType: types.StringType,
@@ -170,23 +178,81 @@ func (r *assignment_groupResource) Schema(_ context.Context, _ resource.SchemaRe
 				Optional:    true,
 				Description: "Optional. Map of Attributes and values set for this Group",
 			},
-			"priority": schema.StringAttribute{
+			"priority": schema.Int64Attribute{
 				Optional:    true,
-				Description: "Optional. The priority (0 to 20) of the assignment group. Default to 0",
-				Default:     stringdefault.StaticString("0"),
+				Description: "Optional. The priority (0 to 20) of the assignment group. Default to 0. The plan warns when other assignment groups of the configuration have the same priority.",
+				Default:     int64default.StaticInt64(0),
 				Computed:    true,
-				Validators: []validator.String{
-					stringvalidator.OneOf("0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16", "17", "18", "19", "20"),
+				Validators: []validator.Int64{
+					int64validator.Between(0, 20),
 				},
 			},
+			"on_partial_failure": onPartialFailureAttribute("assignment group"),
 		},
 	}
 }
 
-// Import function
+// UpgradeState upgrades state saved with older schema versions.
+func (r *assignment_groupResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: releases before on_partial_failure saved no value for it.
+		addStateAttribute("on_partial_failure", partialFailureKeep),
+		// 1 to 2: priority was a string.
+		stateStringToNumber("priority"),
+	)
+}
+
+// ModifyPlan warns when another assignment group of the configuration plans
+// the same priority, SimpleMDM doesn't define which of them wins. Groups are
+// planned one after the other, so the warning shows on whichever of them is
+// planned last and names all of them.
+func (r *assignment_groupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil {
+		return
+	}
+
+	// Existing groups are recorded by their ID, new ones by their
+	// configuration, which stays the same when they are planned again.
+	key := "config " + req.Config.Raw.String()
+	if !req.State.Raw.IsNull() {
+		var id types.String
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+		key = "id " + id.ValueString()
+	}
+
+	var name types.String
+	var priority types.Int64
+	if !req.Plan.Raw.IsNull() {
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("name"), &name)...)
+		// Groups without a configured priority get the default, which
+		// they don't choose to share.
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("priority"), &priority)...)
+	}
+	if resp.Diagnostics.HasError() || req.Plan.Raw.IsNull() || name.IsUnknown() || priority.IsUnknown() || priority.IsNull() {
+		r.client.groupPriorities.remove(key)
+		return
+	}
+
+	others := r.client.groupPriorities.add(key, name.ValueString(), priority.ValueInt64())
+	if len(others) > 0 {
+		names := append(others, name.ValueString())
+		sort.Strings(names)
+		for i := range names {
+			names[i] = strconv.Quote(names[i])
+		}
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("priority"),
+			"Assignment Group Priority Not Unique",
+			fmt.Sprintf("Assignment groups %s have the same priority %d. SimpleMDM doesn't define which of the groups takes precedence, give each group its own priority.", strings.Join(names, ", "), priority.ValueInt64()),
+		)
+	}
+}
+
+// ImportState imports a group by its ID or by name:<group name>.
 func (r *assignment_groupResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importByLookup(ctx, req, resp, "assignment group", map[string]importLookup{
+		"name": assignmentGroupsNamed(r.client),
+	})
 }
 
 // Create a new resource
@@ -200,79 +266,78 @@ func (r *assignment_groupResource) Create(ctx context.Context, req resource.Crea
 	}
 
 	// Generate API request body from plan
-	assignmentgroup, err := r.client.AssignmentGroupCreate(plan.Name.ValueString(), plan.AutoDeploy.ValueBool(), plan.Priority.ValueString(), plan.AppTrackLocation.ValueBool())
+	assignmentgroup, err := r.client.AssignmentGroupCreate(plan.Name.ValueString(), plan.AutoDeploy.ValueBool(), strconv.FormatInt(plan.Priority.ValueInt64(), 10), plan.AppTrackLocation.ValueBool())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating assignment group",
-			"Could not create assignment group, unexpected error: "+err.Error(),
-		)
+			"Could not create assignment group",
+			err,
+		))
 		return
 	}
 
 	plan.ID = types.StringValue(strconv.Itoa(assignmentgroup.Data.ID))
 
+	// The group exists from here on, created tracks what was set up so far so
+	// a failure can save it according to on_partial_failure.
+	created := plan
+	created.Attributes = types.MapNull(types.StringType)
+	created.Apps = nil
+	created.Profiles = types.SetNull(types.StringType)
+	created.Devices = types.SetNull(types.StringType)
+	partialFailure := func() {
+		handlePartialCreate(ctx, resp, plan.OnPartialFailure, "assignment group", plan.ID.ValueString(), created, func() error {
+			return r.client.AssignmentGroupDelete(plan.ID.ValueString())
+		})
+	}
+
 	//setting attributes
+	attributesSet := map[string]attr.Value{}
 	for attribute, value := range plan.Attributes.Elements() {
 		err := r.client.AttributeSetAttributeForDeviceGroup(plan.ID.ValueString(), attribute, strings.Replace(value.String(), "\"", "", 2))
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device group attribute",
-				"Could not set attribute value for device group, unexpected error: "+err.Error(),
-			)
+				"Could not set attribute value for device group",
+				err,
+			))
+			if len(attributesSet) > 0 {
+				created.Attributes, _ = types.MapValue(types.StringType, attributesSet)
+			}
+			partialFailure()
 			return
 		}
+		attributesSet[attribute] = value
 	}
+	created.Attributes = plan.Attributes
 
-	for _, app := range plan.Apps {
-		err := r.client.AssignmentGroupAssignApp(plan.ID.ValueString(), app.AppID.ValueString(), app.DeploymnetType.ValueString(), app.InstallType.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating device group apps",
-				"Could not assing app to device group, unexpected error: "+err.Error(),
-			)
-			return
-		}
-	}
+	// Assign all apps, profiles and devices in plan
+	appsDiags, failedApps := r.updateApps(ctx, plan.ID.ValueString(), plan.Apps, nil)
+	resp.Diagnostics.Append(appsDiags...)
+	created.Apps = appsWithout(plan.Apps, failedApps)
 
-	// Assign all profiles in plan
-	for _, profileId := range plan.Profiles.Elements() {
-		err := r.client.AssignmentGroupAssignObject(plan.ID.ValueString(), strings.Replace(profileId.String(), "\"", "", 2), "profiles")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group profile assignment",
-				"Could not update assignment group profile assignment, unexpected error: "+err.Error(),
-			)
-			return
-		}
-	}
+	profilesDiags, failedProfiles := r.updateObjects(ctx, plan.ID.ValueString(), "profiles", setToStrings(plan.Profiles), nil)
+	resp.Diagnostics.Append(profilesDiags...)
+	created.Profiles = setWithout(plan.Profiles, failedProfiles)
 
-	//assign all devices in plan
-	for _, deviceId := range plan.Devices.Elements() {
-		err := r.client.AssignmentGroupAssignObject(plan.ID.ValueString(), strings.Replace(deviceId.String(), "\"", "", 2), "devices")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group device group assignment",
-				"Could not update assignment group device group, unexpected error: "+err.Error(),
-			)
-			return
-		}
+	devicesDiags, failedDevices := r.updateObjects(ctx, plan.ID.ValueString(), "devices", setToStrings(plan.Devices), nil)
+	resp.Diagnostics.Append(devicesDiags...)
+	created.Devices = setWithout(plan.Devices, failedDevices)
+
+	if resp.Diagnostics.HasError() {
+		partialFailure()
+		return
 	}
 
 	if plan.AppsUpdate.ValueBool() {
 		err := r.client.AssignmentGroupUpdateInstalledApps(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error when sending command to Update Apps, deleting group to prevent issus next run.",
-				"Could not send Apps Update command, unexpected error: "+err.Error(),
-			)
-			err := r.client.AssignmentGroupDelete(plan.ID.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error Deleting SimpleMDM assignment group",
-					"Could not delete assignment group, unexpected error: "+err.Error(),
-				)
-				return
-			}
+			resp.Diagnostics.Append(apiErrorDiagnostic(
+				"Error when sending command to Update Apps",
+				"Could not send Apps Update command",
+				err,
+			))
+			partialFailure()
 			return
 		}
 	}
@@ -280,18 +345,12 @@ func (r *assignment_groupResource) Create(ctx context.Context, req resource.Crea
 	if plan.AppsPush.ValueBool() {
 		err := r.client.AssignmentGroupPushApps(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error when sending command to Push Apps, deleting group to prevent issus next run.",
-				"Could not send Push Apps command, unexpected error: "+err.Error(),
-			)
-			err := r.client.AssignmentGroupDelete(plan.ID.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error Deleting SimpleMDM assignment group",
-					"Could not delete assignment group, unexpected error: "+err.Error(),
-				)
-				return
-			}
+			resp.Diagnostics.Append(apiErrorDiagnostic(
+				"Error when sending command to Push Apps",
+				"Could not send Push Apps command",
+				err,
+			))
+			partialFailure()
 			return
 		}
 	}
@@ -299,18 +358,12 @@ func (r *assignment_groupResource) Create(ctx context.Context, req resource.Crea
 	if plan.ProfilesSync.ValueBool() {
 		err := r.client.AssignmentGroupSyncProfiles(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error when sending command to Sync Profiles, deleting group to prevent issus next run.",
-				"Could not send Sync Profiles command, unexpected error: "+err.Error(),
-			)
-			err := r.client.AssignmentGroupDelete(plan.ID.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error Deleting SimpleMDM assignment group",
-					"Could not delete assignment group, unexpected error: "+err.Error(),
-				)
-				return
-			}
+			resp.Diagnostics.Append(apiErrorDiagnostic(
+				"Error when sending command to Sync Profiles",
+				"Could not send Sync Profiles command",
+				err,
+			))
+			partialFailure()
 			return
 		}
 	}
@@ -336,24 +389,26 @@ func (r *assignment_groupResource) Read(ctx context.Context, req resource.ReadRe
 	// Get refreshed assignment group values from SimpleMDM
 	assignmentGroup, err := r.client.AssignmentGroupGet(state.ID.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM assignment group",
-			"Could not read assignment group ID "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read assignment group ID "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
 	//load attributes for given group
 	attributes, err := r.client.AttributeGetAttributesForGroup(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM device group attributes",
-			"Could not read SimpleMDM device group attributes"+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM device group attributes"+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -377,10 +432,11 @@ func (r *assignment_groupResource) Read(ctx context.Context, req resource.ReadRe
 	// Load all profiles in SimpleMDM
 	profiles, err := r.client.ProfileGetAll()
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM profiles",
-			"Could not read SimpleMDM profiles: "+err.Error(),
-		)
+			"Could not read SimpleMDM profiles",
+			err,
+		))
 		return
 	}
 
@@ -441,7 +497,21 @@ func (r *assignment_groupResource) Read(ctx context.Context, req resource.ReadRe
 	state.Name = types.StringValue(assignmentGroup.Data.Attributes.Name)
 	state.AutoDeploy = types.BoolValue(assignmentGroup.Data.Attributes.AutoDeploy)
 	state.AppTrackLocation = types.BoolValue(assignmentGroup.Data.Attributes.AppTrackLocation)
-	state.Priority = types.StringValue(strconv.Itoa(assignmentGroup.Data.Attributes.Priority))
+	state.Priority = types.Int64Value(int64(assignmentGroup.Data.Attributes.Priority))
+	if state.OnPartialFailure.IsNull() {
+		state.OnPartialFailure = types.StringValue(partialFailureKeep)
+	}
+	// SimpleMDM doesn't store the commands sent after changes, imported
+	// groups get the defaults of the schema.
+	if state.AppsUpdate.IsNull() {
+		state.AppsUpdate = types.BoolValue(true)
+	}
+	if state.AppsPush.IsNull() {
+		state.AppsPush = types.BoolValue(true)
+	}
+	if state.ProfilesSync.IsNull() {
+		state.ProfilesSync = types.BoolValue(true)
+	}
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
@@ -462,73 +532,19 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 		return
 	}
 
-	// compare app in state, add missing apps and remove and re-add apps with missmatch in config of the app
-	for _, planApp := range plan.Apps {
-		found := false
-		update := false
-		for _, stateApp := range state.Apps {
-			if stateApp.AppID == planApp.AppID {
-				found = true
-				if stateApp.DeploymnetType != planApp.DeploymnetType {
-					update = true
-				}
-				if stateApp.InstallType != planApp.InstallType {
-					update = true
-				}
-			}
-		}
-		// app needs update remove it first, and later add it again
-		if update {
-			err := r.client.AssignmentGroupUnAssignApp(plan.ID.ValueString(), planApp.AppID.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error updating device group apps",
-					"Could not un-assing app from device group, unexpected error: "+err.Error(),
-				)
-				return
-			}
-			found = false
-		}
-		if !found {
-			err := r.client.AssignmentGroupAssignApp(plan.ID.ValueString(), planApp.AppID.ValueString(), planApp.DeploymnetType.ValueString(), planApp.InstallType.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error updating device group apps",
-					"Could not assing app to device group, unexpected error: "+err.Error(),
-				)
-				return
-			}
-		}
-
-	}
-
-	//remove any left over apps in state
-	for _, stateApp := range state.Apps {
-		found := false
-		for _, planApp := range plan.Apps {
-			if stateApp.AppID == planApp.AppID {
-				found = true
-			}
-		}
-		if !found {
-			err := r.client.AssignmentGroupUnAssignApp(plan.ID.ValueString(), stateApp.AppID.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error updating device group apps",
-					"Could not un-assing app from device group, unexpected error: "+err.Error(),
-				)
-				return
-			}
-		}
-	}
+	// add missing apps, re-add apps whose settings changed and remove apps
+	// no longer in the plan
+	appsDiags, _ := r.updateApps(ctx, plan.ID.ValueString(), plan.Apps, state.Apps)
+	resp.Diagnostics.Append(appsDiags...)
 
 	// Generate API request body from plan
-	err := r.client.AssignmentGroupUpdate(plan.Name.ValueString(), plan.AutoDeploy.ValueBool(), plan.ID.ValueString(), plan.AppTrackLocation.ValueBool(), plan.Priority.ValueString())
+	err := r.client.AssignmentGroupUpdate(plan.Name.ValueString(), plan.AutoDeploy.ValueBool(), plan.ID.ValueString(), plan.AppTrackLocation.ValueBool(), strconv.FormatInt(plan.Priority.ValueInt64(), 10))
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating assignment group",
-			"Could not update assignment group, unexpected error: "+err.Error(),
-		)
+			"Could not update assignment group",
+			err,
+		))
 		return
 	}
 
@@ -541,10 +557,11 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 				if planValue != stateValue {
 					err := r.client.AttributeSetAttributeForDeviceGroup(plan.ID.ValueString(), planAttribute, strings.Replace(planValue.String(), "\"", "", 2))
 					if err != nil {
-						resp.Diagnostics.AddError(
+						resp.Diagnostics.Append(apiErrorDiagnostic(
 							"Error updating SimpleMDM device group attributes value",
-							"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString()+": "+err.Error(),
-						)
+							"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString(),
+							err,
+						))
 						return
 					}
 				}
@@ -554,10 +571,11 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 		if !found {
 			err := r.client.AttributeSetAttributeForDeviceGroup(plan.ID.ValueString(), planAttribute, strings.Replace(planValue.String(), "\"", "", 2))
 			if err != nil {
-				resp.Diagnostics.AddError(
+				resp.Diagnostics.Append(apiErrorDiagnostic(
 					"Error updating SimpleMDM device group attributes value",
-					"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString()+": "+err.Error(),
-				)
+					"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString(),
+					err,
+				))
 				return
 			}
 		}
@@ -575,100 +593,33 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 		if !found {
 			err := r.client.AttributeSetAttributeForDeviceGroup(plan.ID.ValueString(), stateAttribute, "")
 			if err != nil {
-				resp.Diagnostics.AddError(
+				resp.Diagnostics.Append(apiErrorDiagnostic(
 					"Error updating SimpleMDM device group attributes value",
-					"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString()+": "+err.Error(),
-				)
+					"Could not update SimpleMDM device group attributes value "+plan.ID.ValueString(),
+					err,
+				))
 				return
 			}
 		}
 	}
 
-	//Handling assigned profiles
-	//reading assigned profiles from simpleMDM
-	stateProfiles := []string{}
-	for _, profileId := range state.Profiles.Elements() { //<< edit here
-		stateProfiles = append(stateProfiles, strings.Replace(profileId.String(), "\"", "", 2))
-	}
-
-	//reading configured profiles from TF file
-	planProfiles := []string{}
-	for _, profileId := range plan.Profiles.Elements() {
-		planProfiles = append(planProfiles, strings.Replace(profileId.String(), "\"", "", 2))
-	}
-
-	// creating diff
-	profilesToAdd, profilesToRemove := diffFunction(stateProfiles, planProfiles)
-
-	//adding profiles
-	for _, profileId := range profilesToAdd {
-		err := r.client.AssignmentGroupAssignObject(plan.ID.ValueString(), profileId, "profiles")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group profile assignment",
-				"Could not update assignment group profile assignment, unexpected error: "+err.Error(),
-			)
-			return
-		}
-	}
-
-	//removing profiles
-	for _, profileId := range profilesToRemove {
-		err := r.client.AssignmentGroupUnAssignObject(plan.ID.ValueString(), profileId, "profiles")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group app assignment",
-				"Could not update assignment group app assignment, unexpected error: "+err.Error(),
-			)
-			return
-		}
-	}
-
-	//handling assigned devices
-	//reading currently assigned devices
-	stateDevices := []string{}
-	for _, device := range state.Devices.Elements() {
-		stateDevices = append(stateDevices, strings.Replace(device.String(), "\"", "", 2))
-	}
-	//reading configured apps in TF file
-	planDevices := []string{}
-	for _, device := range plan.Devices.Elements() {
-		planDevices = append(planDevices, strings.Replace(device.String(), "\"", "", 2))
-	}
-	//creating diff
-	devicesToAdd, devicesToRemove := diffFunction(stateDevices, planDevices)
-
-	//devices to add
-	for _, deviceId := range devicesToAdd {
-		err := r.client.AssignmentGroupAssignObject(plan.ID.ValueString(), deviceId, "devices")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group device group assignment",
-				"Could not update assignment group device group, unexpected error: "+err.Error(),
-			)
-			return
-		}
-	}
-
-	//devices to remove
-	for _, deviceId := range devicesToRemove {
-		err := r.client.AssignmentGroupUnAssignObject(plan.ID.ValueString(), deviceId, "devices")
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating assignment group device assignment",
-				"Could not update assignment group device assignment, unexpected error: "+err.Error(),
-			)
-			return
-		}
+	//Handling assigned profiles and devices
+	profilesDiags, _ := r.updateObjects(ctx, plan.ID.ValueString(), "profiles", setToStrings(plan.Profiles), setToStrings(state.Profiles))
+	resp.Diagnostics.Append(profilesDiags...)
+	devicesDiags, _ := r.updateObjects(ctx, plan.ID.ValueString(), "devices", setToStrings(plan.Devices), setToStrings(state.Devices))
+	resp.Diagnostics.Append(devicesDiags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	if plan.AppsUpdate.ValueBool() {
 		err := r.client.AssignmentGroupUpdateInstalledApps(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating assignment App update failed",
-				"Could not update assignment App update failed, unexpected error: "+err.Error(),
-			)
+				"Could not update assignment App update failed",
+				err,
+			))
 			return
 		}
 	}
@@ -676,10 +627,11 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 	if plan.AppsPush.ValueBool() {
 		err := r.client.AssignmentGroupPushApps(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating assignment App push failed",
-				"Could not update assignment App push failed, unexpected error: "+err.Error(),
-			)
+				"Could not update assignment App push failed",
+				err,
+			))
 			return
 		}
 	}
@@ -687,10 +639,11 @@ func (r *assignment_groupResource) Update(ctx context.Context, req resource.Upda
 	if plan.ProfilesSync.ValueBool() {
 		err := r.client.AssignmentGroupSyncProfiles(plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating assignment group profile sync",
-				"Could not update assignment group profile sync, unexpected error: "+err.Error(),
-			)
+				"Could not update assignment group profile sync",
+				err,
+			))
 			return
 		}
 	}
@@ -713,15 +666,147 @@ func (r *assignment_groupResource) Delete(ctx context.Context, req resource.Dele
 
 	// Delete existing assignment group
 	err := r.client.AssignmentGroupDelete(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM assignment group",
-			"Could not delete assignment group, unexpected error: "+err.Error(),
-		)
+			"Could not delete assignment group",
+			err,
+		))
 		return
 	}
 }
 
+// updateApps assigns the apps in plan which are missing from state, removes
+// and re-assigns apps whose deployment or install type changed and removes
+// apps which are no longer in plan. The calls run concurrently, all failures
+// are reported together, the IDs of the apps which could not be assigned are
+// returned.
+func (r *assignment_groupResource) updateApps(ctx context.Context, groupID string, plan []appModel, state []appModel) (diag.Diagnostics, []string) {
+	var diags diag.Diagnostics
+
+	stateApps := map[string]appModel{}
+	for _, app := range state {
+		stateApps[app.AppID.ValueString()] = app
+	}
+	planApps := map[string]appModel{}
+	toAssign := []string{}
+	for _, app := range plan {
+		id := app.AppID.ValueString()
+		planApps[id] = app
+		stateApp, found := stateApps[id]
+		if !found || stateApp.DeploymnetType != app.DeploymnetType || stateApp.InstallType != app.InstallType {
+			toAssign = append(toAssign, id)
+		}
+	}
+	toRemove := []string{}
+	for _, app := range state {
+		if _, found := planApps[app.AppID.ValueString()]; !found {
+			toRemove = append(toRemove, app.AppID.ValueString())
+		}
+	}
+
+	failures := r.client.forEach(ctx, toAssign, func(id string) error {
+		app := planApps[id]
+		// the API can't change an assignment, remove it first and add it
+		// again
+		if _, found := stateApps[id]; found {
+			if err := r.client.AssignmentGroupUnAssignApp(groupID, id); err != nil {
+				return err
+			}
+		}
+		return r.client.AssignmentGroupAssignApp(groupID, id, app.DeploymnetType.ValueString(), app.InstallType.ValueString())
+	})
+	if len(failures) > 0 {
+		diags.Append(taskFailuresDiagnostic(
+			"Error updating device group apps",
+			"Could not assign app to device group "+groupID,
+			len(toAssign),
+			failures,
+		))
+	}
+	unassigned := failedIDs(failures)
+
+	failures = r.client.forEach(ctx, toRemove, func(id string) error {
+		return r.client.AssignmentGroupUnAssignApp(groupID, id)
+	})
+	if len(failures) > 0 {
+		diags.Append(taskFailuresDiagnostic(
+			"Error updating device group apps",
+			"Could not un-assign app from device group "+groupID,
+			len(toRemove),
+			failures,
+		))
+	}
+
+	return diags, unassigned
+}
+
+// updateObjects assigns the objects of kind ("profiles" or "devices") which
+// are in plan but not in state and removes those only in state. The calls
+// run concurrently, all failures are reported together, the IDs of the
+// objects which could not be assigned are returned.
+func (r *assignment_groupResource) updateObjects(ctx context.Context, groupID string, kind string, plan []string, state []string) (diag.Diagnostics, []string) {
+	var diags diag.Diagnostics
+	toAdd, toRemove := diffFunction(state, plan)
+
+	failures := r.client.forEach(ctx, toAdd, func(id string) error {
+		return r.client.AssignmentGroupAssignObject(groupID, id, kind)
+	})
+	if len(failures) > 0 {
+		diags.Append(taskFailuresDiagnostic(
+			"Error updating assignment group "+kind+" assignment",
+			"Could not assign "+kind+" to assignment group "+groupID,
+			len(toAdd),
+			failures,
+		))
+	}
+	unassigned := failedIDs(failures)
+
+	failures = r.client.forEach(ctx, toRemove, func(id string) error {
+		return r.client.AssignmentGroupUnAssignObject(groupID, id, kind)
+	})
+	if len(failures) > 0 {
+		diags.Append(taskFailuresDiagnostic(
+			"Error updating assignment group "+kind+" assignment",
+			"Could not un-assign "+kind+" from assignment group "+groupID,
+			len(toRemove),
+			failures,
+		))
+	}
+
+	return diags, unassigned
+}
+
+// appsWithout returns apps without the ones with the given IDs.
+func appsWithout(apps []appModel, ids []string) []appModel {
+	if len(ids) == 0 {
+		return apps
+	}
+	remove := map[string]bool{}
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := []appModel{}
+	for _, app := range apps {
+		if !remove[app.AppID.ValueString()] {
+			kept = append(kept, app)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// setToStrings returns the elements of a set of strings.
+func setToStrings(set types.Set) []string {
+	values := []string{}
+	for _, value := range set.Elements() {
+		values = append(values, strings.Replace(value.String(), "\"", "", 2))
+	}
+	return values
+}
+
 // helper function to get diff between two groups
 func diffFunction(state []string, plan []string) (add []string, remove []string) {
 	IDsToAdd := []string{}
@@ -1,8 +1,15 @@
 package provider
 
 import (
+	"context"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
 
@@ -43,13 +50,22 @@ func TestAccAssignmentGroupResource(t *testing.T) {
 					resource.TestCheckResourceAttrSet("simplemdm_assignmentgroup.testgroup2", "id"),
 				),
 			},
-			// ImportState testing
+			// ImportState testing, apps_update, apps_push and profiles_sync
+			// are only sent to SimpleMDM and imported with their defaults.
 			{
 				ResourceName:            "simplemdm_assignmentgroup.testgroup2",
 				ImportState:             true,
 				ImportStateVerify:       true,
 				ImportStateVerifyIgnore: []string{"apps_update", "apps_push", "auto_deploy", "profiles_sync", "install_type", "profiles"},
 			},
+			// Import by name
+			{
+				ResourceName:            "simplemdm_assignmentgroup.testgroup2",
+				ImportState:             true,
+				ImportStateId:           "name:This assignment group",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"apps_update", "apps_push", "auto_deploy", "profiles_sync", "install_type", "profiles"},
+			},
 			//Update and Read testing
 			{
 				Config: providerConfig + `
@@ -79,3 +95,100 @@ func TestAccAssignmentGroupResource(t *testing.T) {
 		},
 	})
 }
+
+func TestAccAssignmentGroupResourcePriorityRange(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				resource "simplemdm_assignmentgroup" "outofrange" {
+					name     = "out of range"
+					priority = 21
+				  }
+			`,
+				ExpectError: regexp.MustCompile(`Attribute priority value must be between 0 and 20, got: 21`),
+			},
+		},
+	})
+}
+
+// testAssignmentGroupModifyPlan plans a group with the given name and
+// configured priority on r and returns the diagnostics. The group is new
+// when id is empty.
+func testAssignmentGroupModifyPlan(t *testing.T, r *assignment_groupResource, id, name string, priority types.Int64) diag.Diagnostics {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	model := testAssignmentGroupPlan(partialFailureKeep)
+	model.Name = types.StringValue(name)
+	model.Priority = priority
+	configured := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := configured.Set(ctx, model); diags.HasError() {
+		t.Fatal(diags)
+	}
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: configured.Raw}
+	if priority.IsNull() {
+		model.Priority = types.Int64Value(0)
+	}
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := plan.Set(ctx, model); diags.HasError() {
+		t.Fatal(diags)
+	}
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: nullObject(schemaResp.Schema.Type().TerraformType(ctx))}
+	if id != "" {
+		model.ID = types.StringValue(id)
+		if diags := state.Set(ctx, model); diags.HasError() {
+			t.Fatal(diags)
+		}
+	}
+
+	resp := &fwresource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{Config: config, Plan: plan, State: state}, resp)
+	return resp.Diagnostics
+}
+
+func TestAssignmentGroupWarnsAboutSharedPriority(t *testing.T) {
+	r := &assignment_groupResource{client: &simplemdmClient{}}
+
+	if diags := testAssignmentGroupModifyPlan(t, r, "1", "Engineering", types.Int64Value(5)); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for the first group, got %v", diags)
+	}
+	if diags := testAssignmentGroupModifyPlan(t, r, "2", "Sales", types.Int64Value(4)); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for another priority, got %v", diags)
+	}
+	// Planning the same group again doesn't make it share the priority.
+	if diags := testAssignmentGroupModifyPlan(t, r, "1", "Engineering", types.Int64Value(5)); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics planning the group again, got %v", diags)
+	}
+	// Groups without a configured priority share the default silently.
+	for _, id := range []string{"3", "4"} {
+		if diags := testAssignmentGroupModifyPlan(t, r, id, "Default", types.Int64Null()); len(diags) != 0 {
+			t.Fatalf("expected no diagnostics for the default priority, got %v", diags)
+		}
+	}
+
+	diags := testAssignmentGroupModifyPlan(t, r, "", "Marketing", types.Int64Value(5))
+	if diags.WarningsCount() != 1 || diags.HasError() {
+		t.Fatalf("expected a warning, got %v", diags)
+	}
+	if detail := diags.Warnings()[0].Detail(); !strings.Contains(detail, `Assignment groups "Engineering", "Marketing" have the same priority 5.`) {
+		t.Errorf("unexpected warning %q", detail)
+	}
+
+	// A group planned with another priority no longer shares the old one.
+	if diags := testAssignmentGroupModifyPlan(t, r, "1", "Engineering", types.Int64Value(6)); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for the changed priority, got %v", diags)
+	}
+	if diags := testAssignmentGroupModifyPlan(t, r, "", "Marketing", types.Int64Value(5)); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics once the priority is unique, got %v", diags)
+	}
+
+	// Groups with the same name are told apart.
+	diags = testAssignmentGroupModifyPlan(t, r, "5", "Engineering", types.Int64Value(6))
+	if detail := diags.Warnings(); len(detail) != 1 || !strings.Contains(detail[0].Detail(), `Assignment groups "Engineering", "Engineering" have the same priority 6.`) {
+		t.Errorf("expected a warning for the group with the same name, got %v", diags)
+	}
+}
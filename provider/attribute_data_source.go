@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -29,7 +28,7 @@ func AttributeDataSource() datasource.DataSource {
 
 // AttributeDataSource is the data source implementation.
 type attributeDataSource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Metadata returns the data source type name.
@@ -62,10 +61,11 @@ func (d *attributeDataSource) Read(ctx context.Context, req datasource.ReadReque
 
 	attribute, err := d.client.AttributeGet(state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Unable to Read SimpleMDM attribute",
-			err.Error(),
-		)
+			"",
+			err,
+		))
 		return
 	}
 
@@ -88,11 +88,11 @@ func (d *attributeDataSource) Configure(_ context.Context, req datasource.Config
 		return
 	}
 
-	client, ok := req.ProviderData.(*simplemdm.Client)
+	client, ok := req.ProviderData.(*simplemdmClient)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *simplemdmClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
@@ -2,9 +2,7 @@ package provider
 
 import (
 	"context"
-	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -15,9 +13,10 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &attributeResource{}
-	_ resource.ResourceWithConfigure   = &attributeResource{}
-	_ resource.ResourceWithImportState = &attributeResource{}
+	_ resource.Resource                 = &attributeResource{}
+	_ resource.ResourceWithConfigure    = &attributeResource{}
+	_ resource.ResourceWithImportState  = &attributeResource{}
+	_ resource.ResourceWithUpgradeState = &attributeResource{}
 )
 
 // attributeResourceModel maps the resource schema data.
@@ -34,7 +33,7 @@ func AttributeResource() resource.Resource {
 
 // AttributeResource is the resource implementation.
 type attributeResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -43,7 +42,7 @@ func (r *attributeResource) Configure(_ context.Context, req resource.ConfigureR
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -54,6 +53,7 @@ func (r *attributeResource) Metadata(_ context.Context, req resource.MetadataReq
 // Schema defines the schema for the resource.
 func (r *attributeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Attribute resourse can be used to manage SimpleMDM Attribute. Can be used together with Device(s) or Device Group(s) to set values or in lifecycle management.",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -79,6 +79,14 @@ func (r *attributeResource) Schema(_ context.Context, _ resource.SchemaRequest,
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *attributeResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
 func (r *attributeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Retrieve import ID and save to id attribute
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
@@ -97,10 +105,11 @@ func (r *attributeResource) Create(ctx context.Context, req resource.CreateReque
 	// Generate API request body from plan
 	_, err := r.client.AttributeCreate(plan.Name.ValueString(), plan.DefaultValue.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating attribute",
-			"Could not create attribute, unexpected error: "+err.Error(),
-		)
+			"Could not create attribute",
+			err,
+		))
 		return
 	}
 
@@ -134,14 +143,15 @@ func (r *attributeResource) Read(ctx context.Context, req resource.ReadRequest,
 	// Get refreshed attribute value from SimpleMDM
 	attribute, err := r.client.AttributeGet(state.Name.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM Attribute",
-			"Could not read SimpleMDM Attribute ID "+state.Name.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM Attribute ID "+state.Name.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -171,10 +181,11 @@ func (r *attributeResource) Update(ctx context.Context, req resource.UpdateReque
 	// Generate API request body from plan
 	err := r.client.AttributeUpdate(plan.Name.ValueString(), plan.DefaultValue.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating attribute",
-			"Could not create attribute, unexpected error: "+err.Error(),
-		)
+			"Could not create attribute",
+			err,
+		))
 		return
 	}
 
@@ -195,11 +206,12 @@ func (r *attributeResource) Delete(ctx context.Context, req resource.DeleteReque
 
 	// Delete existing attribute
 	err := r.client.AttributeDelete(state.Name.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM attribute",
-			"Could not attribute, unexpected error: "+err.Error(),
-		)
+			"Could not attribute",
+			err,
+		))
 		return
 	}
 }
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/DavidKrau/simplemdm-go-client"
+)
+
+// simplemdmClient is the ProviderData handed to every resource and data
+// source. It embeds the SimpleMDM API client, so API calls are made on it
+// directly, and carries the state shared by everything the provider
+// configured.
+type simplemdmClient struct {
+	*simplemdm.Client
+
+	// apiKey authenticates the calls the provider makes itself, see apiGet.
+	apiKey string
+
+	// cache holds list responses shared between all resources and data
+	// sources of this provider instance.
+	cache *listCache
+
+	// maxConcurrency limits how many API calls a single fan-out operation
+	// makes at the same time.
+	maxConcurrency int
+
+	// groupPriorities collects the priorities planned for assignment groups.
+	groupPriorities groupPriorities
+
+	// deviceProfiles maps devices to the profiles assigned to them.
+	deviceProfiles deviceProfileIndex
+}
+
+// groupPriorities records which assignment groups plan which priority, for
+// warning about groups sharing one. The zero value is ready to use.
+type groupPriorities struct {
+	mu     sync.Mutex
+	groups map[string]plannedPriority
+}
+
+// plannedPriority is the priority planned for a named assignment group.
+type plannedPriority struct {
+	name     string
+	priority int64
+}
+
+// add records that the group identified by key plans priority, replacing
+// what it planned before, and returns the names of the other groups planning
+// the same, sorted.
+func (g *groupPriorities) add(key, name string, priority int64) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.groups == nil {
+		g.groups = map[string]plannedPriority{}
+	}
+	g.groups[key] = plannedPriority{name: name, priority: priority}
+
+	var others []string
+	for other, planned := range g.groups {
+		if other != key && planned.priority == priority {
+			others = append(others, planned.name)
+		}
+	}
+	sort.Strings(others)
+	return others
+}
+
+// remove forgets the priority planned for the group identified by key.
+func (g *groupPriorities) remove(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.groups, key)
+}
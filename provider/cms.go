@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Signed configuration profiles are CMS (PKCS#7) SignedData structures with
+// the plist as the encapsulated content, see RFC 5652.
+
+var (
+	oidCMSSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidCMSData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+)
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo cmsContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// cmsSigner describes who signed a profile. The signature itself is not
+// verified, devices do that when they install the profile.
+type cmsSigner struct {
+	Subject           string
+	Issuer            string
+	SerialNumber      string
+	NotBefore         time.Time
+	NotAfter          time.Time
+	SHA256Fingerprint string
+}
+
+// looksLikeCMS reports whether content starts like a DER encoded structure
+// rather than a property list.
+func looksLikeCMS(content []byte) bool {
+	return len(content) > 1 && content[0] == 0x30 && content[1] >= 0x80
+}
+
+// unwrapCMS returns the content of a CMS SignedData structure and the
+// signers it names.
+func unwrapCMS(content []byte) ([]byte, []cmsSigner, error) {
+	var info cmsContentInfo
+	if _, err := asn1.Unmarshal(content, &info); err != nil {
+		return nil, nil, fmt.Errorf("invalid CMS structure: %w", err)
+	}
+	if !info.ContentType.Equal(oidCMSSignedData) {
+		return nil, nil, fmt.Errorf("CMS content type %s is not signed data", info.ContentType)
+	}
+
+	var signed cmsSignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+		return nil, nil, fmt.Errorf("invalid CMS signed data: %w", err)
+	}
+	if !signed.EncapContentInfo.ContentType.Equal(oidCMSData) {
+		return nil, nil, fmt.Errorf("CMS signed content type %s is not data", signed.EncapContentInfo.ContentType)
+	}
+	if len(signed.EncapContentInfo.Content.FullBytes) == 0 {
+		return nil, nil, errors.New("the CMS signature is detached, the profile itself is missing")
+	}
+	// Explicitly tagged raw values keep the tag, the octet string is inside.
+	var octets asn1.RawValue
+	if _, err := asn1.Unmarshal(signed.EncapContentInfo.Content.Bytes, &octets); err != nil {
+		return nil, nil, fmt.Errorf("invalid CMS content: %w", err)
+	}
+	payload, err := cmsOctets(octets)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var certificates []*x509.Certificate
+	if len(signed.Certificates.Bytes) > 0 {
+		certificates, err = x509.ParseCertificates(signed.Certificates.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid certificate in the CMS signature: %w", err)
+		}
+	}
+
+	signers := []cmsSigner{}
+	for _, signerInfo := range signed.SignerInfos {
+		certificate := cmsSignerCertificate(signerInfo.SID, certificates)
+		if certificate == nil {
+			signers = append(signers, cmsSigner{Subject: "unknown, the signing certificate is not included"})
+			continue
+		}
+		fingerprint := sha256.Sum256(certificate.Raw)
+		signers = append(signers, cmsSigner{
+			Subject:           certificate.Subject.String(),
+			Issuer:            certificate.Issuer.String(),
+			SerialNumber:      certificate.SerialNumber.Text(16),
+			NotBefore:         certificate.NotBefore.UTC(),
+			NotAfter:          certificate.NotAfter.UTC(),
+			SHA256Fingerprint: hex.EncodeToString(fingerprint[:]),
+		})
+	}
+	return payload, signers, nil
+}
+
+// cmsOctets returns the bytes of an OCTET STRING, also in the constructed
+// form some signers use which splits them in chunks.
+func cmsOctets(value asn1.RawValue) ([]byte, error) {
+	if value.Class != asn1.ClassUniversal || value.Tag != asn1.TagOctetString {
+		return nil, fmt.Errorf("CMS content is not an octet string")
+	}
+	if !value.IsCompound {
+		return value.Bytes, nil
+	}
+	var content bytes.Buffer
+	rest := value.Bytes
+	for len(rest) > 0 {
+		var chunk asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &chunk)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CMS content: %w", err)
+		}
+		octets, err := cmsOctets(chunk)
+		if err != nil {
+			return nil, err
+		}
+		content.Write(octets)
+	}
+	return content.Bytes(), nil
+}
+
+// cmsSignerCertificate finds the certificate a signer identifier refers to,
+// by issuer and serial number or by subject key identifier.
+func cmsSignerCertificate(sid asn1.RawValue, certificates []*x509.Certificate) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, certificate := range certificates {
+			if bytes.Equal(certificate.SubjectKeyId, sid.Bytes) {
+				return certificate
+			}
+		}
+		return nil
+	}
+
+	var issuerAndSerial cmsIssuerAndSerialNumber
+	if _, err := asn1.Unmarshal(sid.FullBytes, &issuerAndSerial); err != nil {
+		return nil
+	}
+	for _, certificate := range certificates {
+		if certificate.SerialNumber.Cmp(issuerAndSerial.SerialNumber) == 0 && bytes.Equal(certificate.RawIssuer, issuerAndSerial.Issuer.FullBytes) {
+			return certificate
+		}
+	}
+	return nil
+}
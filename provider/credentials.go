@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+const (
+	defaultCredentialsProfile = "default"
+
+	// apikeyCommandTimeout limits how long apikey_command may run.
+	apikeyCommandTimeout = 30 * time.Second
+)
+
+// credentials are the API key and optionally the host the provider uses,
+// together with a description of where the key was found.
+type credentials struct {
+	APIKey string
+	Host   string
+	Source string
+}
+
+// resolveCredentials finds the API key, the first source which is set wins:
+//
+//  1. the apikey attribute
+//  2. the file named by the apikey_file attribute
+//  3. the output of the apikey_command attribute
+//  4. the credentials file profile named by the profile attribute
+//  5. the SIMPLEMDM_APIKEY environment variable
+//  6. the credentials file profile named by SIMPLEMDM_PROFILE
+//  7. the "default" profile of the credentials file, if the file exists
+//
+// A profile can also set the host, it is used when neither the host
+// attribute nor SIMPLEMDM_HOST are set.
+func resolveCredentials(ctx context.Context, config simplemdmProviderModel) (credentials, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	switch {
+	case !config.APIKey.IsNull():
+		return credentials{APIKey: config.APIKey.ValueString(), Source: "apikey"}, diags
+
+	case !config.APIKeyFile.IsNull():
+		apikey, err := readAPIKeyFile(config.APIKeyFile.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("apikey_file"),
+				"Unable to Read SimpleMDM API Key File",
+				"The provider cannot read the SimpleMDM API key from "+config.APIKeyFile.ValueString()+": "+err.Error(),
+			)
+		}
+		return credentials{APIKey: apikey, Source: "apikey_file"}, diags
+
+	case !config.APIKeyCommand.IsNull():
+		apikey, err := runAPIKeyCommand(ctx, config.APIKeyCommand.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("apikey_command"),
+				"Unable to Run SimpleMDM API Key Command",
+				"The provider cannot get the SimpleMDM API key from apikey_command: "+err.Error(),
+			)
+		}
+		return credentials{APIKey: apikey, Source: "apikey_command"}, diags
+
+	case !config.Profile.IsNull():
+		return profileCredentials(config.Profile.ValueString(), path.Root("profile"), true)
+
+	case os.Getenv("SIMPLEMDM_APIKEY") != "":
+		return credentials{APIKey: os.Getenv("SIMPLEMDM_APIKEY"), Source: "SIMPLEMDM_APIKEY"}, diags
+
+	case os.Getenv("SIMPLEMDM_PROFILE") != "":
+		return profileCredentials(os.Getenv("SIMPLEMDM_PROFILE"), path.Root("profile"), true)
+	}
+
+	return profileCredentials(defaultCredentialsProfile, path.Root("profile"), false)
+}
+
+// credentialsFilePath returns SIMPLEMDM_CREDENTIALS_FILE if set, otherwise
+// ~/.config/simplemdm/credentials.
+func credentialsFilePath() (string, error) {
+	if file := os.Getenv("SIMPLEMDM_CREDENTIALS_FILE"); file != "" {
+		return expandHome(file)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "simplemdm", "credentials"), nil
+}
+
+// profileCredentials reads a profile from the credentials file. A missing
+// file is only an error when the profile was asked for explicitly.
+func profileCredentials(profile string, attributePath path.Path, required bool) (credentials, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	source := "profile " + profile
+
+	file, err := credentialsFilePath()
+	if err != nil {
+		if required {
+			diags.AddAttributeError(attributePath, "Unable to Locate SimpleMDM Credentials File", err.Error())
+		}
+		return credentials{Source: source}, diags
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if required || !errors.Is(err, fs.ErrNotExist) {
+			diags.AddAttributeError(
+				attributePath,
+				"Unable to Read SimpleMDM Credentials File",
+				"The provider cannot read the SimpleMDM credentials file "+file+": "+err.Error(),
+			)
+		}
+		return credentials{Source: source}, diags
+	}
+
+	profiles, err := parseCredentialsFile(content)
+	if err != nil {
+		diags.AddAttributeError(
+			attributePath,
+			"Invalid SimpleMDM Credentials File",
+			"The provider cannot parse the SimpleMDM credentials file "+file+": "+err.Error(),
+		)
+		return credentials{Source: source}, diags
+	}
+
+	values, ok := profiles[profile]
+	if !ok {
+		if required {
+			diags.AddAttributeError(
+				attributePath,
+				"Unknown SimpleMDM Credentials Profile",
+				fmt.Sprintf("The SimpleMDM credentials file %s has no [%s] section.", file, profile),
+			)
+		}
+		return credentials{Source: source}, diags
+	}
+
+	return credentials{APIKey: values["apikey"], Host: values["host"], Source: source + " in " + file}, diags
+}
+
+// parseCredentialsFile parses the INI style credentials file:
+//
+//	[prod]
+//	apikey = ...
+//	host = a.simplemdm.com
+//
+// Lines starting with # or ; are comments, values may be quoted.
+func parseCredentialsFile(content []byte) (map[string]map[string]string, error) {
+	profiles := map[string]map[string]string{}
+	var current map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: section header is missing the closing bracket", lineNumber)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: section name is empty", lineNumber)
+			}
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			current = profiles[name]
+		default:
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+			}
+			if current == nil {
+				return nil, fmt.Errorf("line %d: %s is set outside of a [profile] section", lineNumber, strings.TrimSpace(key))
+			}
+			value = strings.TrimSpace(value)
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+			current[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+	return profiles, scanner.Err()
+}
+
+func readAPIKeyFile(file string) (string, error) {
+	file, err := expandHome(file)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	apikey := strings.TrimSpace(string(content))
+	if apikey == "" {
+		return "", errors.New("the file is empty")
+	}
+	return apikey, nil
+}
+
+// runAPIKeyCommand runs command with the system shell and returns the first
+// line of its output.
+func runAPIKeyCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, apikeyCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+
+	apikey, _, _ := strings.Cut(strings.TrimSpace(stdout.String()), "\n")
+	apikey = strings.TrimSpace(apikey)
+	if apikey == "" {
+		return "", errors.New("the command printed nothing to stdout")
+	}
+	return apikey, nil
+}
+
+func expandHome(file string) (string, error) {
+	if file != "~" && !strings.HasPrefix(file, "~/") {
+		return file, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(file, "~")), nil
+}
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testCredentialsFile = `
+# SimpleMDM accounts
+[default]
+apikey = default-key
+
+[prod]
+apikey = "prod-key"
+host = eu.simplemdm.com
+
+; staging shares the default host
+[staging]
+apikey=staging-key
+`
+
+func TestResolveCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentialsFile, []byte(testCredentialsFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "apikey")
+	if err := os.WriteFile(keyFile, []byte("  file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	null := types.StringNull()
+	tests := map[string]struct {
+		config       simplemdmProviderModel
+		env          map[string]string
+		expectKey    string
+		expectHost   string
+		expectSource string
+		expectError  bool
+	}{
+		"apikey attribute wins over everything": {
+			config:       simplemdmProviderModel{APIKey: types.StringValue("attribute-key"), APIKeyFile: null, APIKeyCommand: null, Profile: null},
+			env:          map[string]string{"SIMPLEMDM_APIKEY": "env-key", "SIMPLEMDM_PROFILE": "prod"},
+			expectKey:    "attribute-key",
+			expectSource: "apikey",
+		},
+		"apikey_file": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: types.StringValue(keyFile), APIKeyCommand: null, Profile: null},
+			env:          map[string]string{"SIMPLEMDM_APIKEY": "env-key"},
+			expectKey:    "file-key",
+			expectSource: "apikey_file",
+		},
+		"missing apikey_file": {
+			config:      simplemdmProviderModel{APIKey: null, APIKeyFile: types.StringValue(filepath.Join(dir, "missing")), APIKeyCommand: null, Profile: null},
+			expectError: true,
+		},
+		"profile attribute wins over environment": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: types.StringValue("prod")},
+			env:          map[string]string{"SIMPLEMDM_APIKEY": "env-key"},
+			expectKey:    "prod-key",
+			expectHost:   "eu.simplemdm.com",
+			expectSource: "profile prod in " + credentialsFile,
+		},
+		"unknown profile": {
+			config:      simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: types.StringValue("qa")},
+			expectError: true,
+		},
+		"environment key wins over environment profile": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: null},
+			env:          map[string]string{"SIMPLEMDM_APIKEY": "env-key", "SIMPLEMDM_PROFILE": "prod"},
+			expectKey:    "env-key",
+			expectSource: "SIMPLEMDM_APIKEY",
+		},
+		"environment profile": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: null},
+			env:          map[string]string{"SIMPLEMDM_PROFILE": "staging"},
+			expectKey:    "staging-key",
+			expectSource: "profile staging in " + credentialsFile,
+		},
+		"default profile": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: null},
+			expectKey:    "default-key",
+			expectSource: "profile default in " + credentialsFile,
+		},
+		"no credentials file is not an error": {
+			config:       simplemdmProviderModel{APIKey: null, APIKeyFile: null, APIKeyCommand: null, Profile: null},
+			env:          map[string]string{"SIMPLEMDM_CREDENTIALS_FILE": filepath.Join(dir, "missing")},
+			expectKey:    "",
+			expectSource: "profile default",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("SIMPLEMDM_APIKEY", "")
+			t.Setenv("SIMPLEMDM_PROFILE", "")
+			t.Setenv("SIMPLEMDM_CREDENTIALS_FILE", credentialsFile)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			creds, diags := resolveCredentials(context.Background(), test.config)
+			if diags.HasError() != test.expectError {
+				t.Fatalf("expected error %t, got %v", test.expectError, diags)
+			}
+			if test.expectError {
+				return
+			}
+			if creds.APIKey != test.expectKey || creds.Host != test.expectHost || creds.Source != test.expectSource {
+				t.Errorf("expected %q %q %q, got %q %q %q", test.expectKey, test.expectHost, test.expectSource, creds.APIKey, creds.Host, creds.Source)
+			}
+		})
+	}
+}
+
+func TestResolveCredentialsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test command needs a POSIX shell")
+	}
+	null := types.StringNull()
+
+	creds, diags := resolveCredentials(context.Background(), simplemdmProviderModel{
+		APIKey: null, APIKeyFile: null, Profile: null,
+		APIKeyCommand: types.StringValue("printf 'command-key\\nsecond line\\n'"),
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if creds.APIKey != "command-key" {
+		t.Errorf("expected the first line of the output, got %q", creds.APIKey)
+	}
+
+	_, diags = resolveCredentials(context.Background(), simplemdmProviderModel{
+		APIKey: null, APIKeyFile: null, Profile: null,
+		APIKeyCommand: types.StringValue("echo vault is sealed >&2; exit 3"),
+	})
+	if !diags.HasError() || !strings.Contains(diags.Errors()[0].Detail(), "vault is sealed") {
+		t.Errorf("expected the command failure with its stderr, got %v", diags)
+	}
+}
+
+func TestParseCredentialsFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"key outside section": "apikey = x\n",
+		"unclosed section":    "[prod\napikey = x\n",
+		"missing equals":      "[prod]\napikey\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseCredentialsFile([]byte(content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
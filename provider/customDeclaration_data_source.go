@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -30,7 +29,7 @@ func CustomDeclarationDataSource() datasource.DataSource {
 
 // AttributeDataSource is the data source implementation.
 type customDeclarationDataSource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Metadata returns the data source type name.
@@ -63,10 +62,11 @@ func (d *customDeclarationDataSource) Read(ctx context.Context, req datasource.R
 
 	declaration, err := d.client.ProfileGet(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Unable to Read SimpleMDM custom declaration",
-			err.Error(),
-		)
+			"",
+			err,
+		))
 		return
 	}
 
@@ -89,11 +89,11 @@ func (d *customDeclarationDataSource) Configure(_ context.Context, req datasourc
 		return
 	}
 
-	client, ok := req.ProviderData.(*simplemdm.Client)
+	client, ok := req.ProviderData.(*simplemdmClient)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *simplemdmClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
@@ -3,35 +3,38 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &customDeclarationResource{}
-	_ resource.ResourceWithConfigure   = &customDeclarationResource{}
-	_ resource.ResourceWithImportState = &customDeclarationResource{}
+	_ resource.Resource                   = &customDeclarationResource{}
+	_ resource.ResourceWithConfigure      = &customDeclarationResource{}
+	_ resource.ResourceWithImportState    = &customDeclarationResource{}
+	_ resource.ResourceWithUpgradeState   = &customDeclarationResource{}
+	_ resource.ResourceWithValidateConfig = &customDeclarationResource{}
 )
 
 // declarationResourceModel maps the resource schema data.
 type customDeclarationResourceModel struct {
-	Name                  types.String `tfsdk:"name"`
-	Declaration           types.String `tfsdk:"declaration"`
-	DeclarationType       types.String `tfsdk:"declaration_type"`
-	UserScope             types.Bool   `tfsdk:"userscope"`
-	AttributeSupport      types.Bool   `tfsdk:"attributesupport"`
-	EscapeAttributes      types.Bool   `tfsdk:"escapeattributes"`
-	ActivatetionPredicate types.String `tfsdk:"activation_predicate"`
-	ID                    types.String `tfsdk:"id"`
+	Name                  types.String         `tfsdk:"name"`
+	Declaration           declarationJSONValue `tfsdk:"declaration"`
+	DeclarationType       types.String         `tfsdk:"declaration_type"`
+	UserScope             types.Bool           `tfsdk:"userscope"`
+	AttributeSupport      types.Bool           `tfsdk:"attributesupport"`
+	EscapeAttributes      types.Bool           `tfsdk:"escapeattributes"`
+	ActivatetionPredicate types.String         `tfsdk:"activation_predicate"`
+	ID                    types.String         `tfsdk:"id"`
 }
 
 // DeclarationResource is a helper function to simplify the provider implementation.
@@ -41,7 +44,7 @@ func CustomDeclarationResource() resource.Resource {
 
 // declarationResource is the resource implementation.
 type customDeclarationResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -50,7 +53,7 @@ func (r *customDeclarationResource) Configure(_ context.Context, req resource.Co
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -61,6 +64,7 @@ func (r *customDeclarationResource) Metadata(_ context.Context, req resource.Met
 // Schema defines the schema for the resource.
 func (r *customDeclarationResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Custom Declaration resource can be used to manage Custom Declaration. Can be used together with Device(s) and Group(s) and set addition details regarding Custom Declaration.",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -69,14 +73,18 @@ func (r *customDeclarationResource) Schema(_ context.Context, _ resource.SchemaR
 				Description: "Required. A name for the declaration. Example: \"My First declaration by terraform\"",
 			},
 			"declaration": schema.StringAttribute{
+				CustomType:  declarationJSONType{},
 				Required:    true,
 				Optional:    false,
-				Description: "Required. Can be string or you can use function 'file' or 'templatefile' to load string from file (see examples folder). Example: declaration = file(\"./declarations/declaration.json\") or declaration = <<-EOT DECLARATION STRING EOT",
+				Description: "Required. Can be string or you can use function 'file' or 'templatefile' to load string from file (see examples folder). Example: declaration = file(\"./declarations/declaration.json\") or declaration = <<-EOT DECLARATION STRING EOT. Must be a JSON object, its formatting and key order don't matter and the activation_predicate and declaration_name keys SimpleMDM adds are ignored.",
 			},
 			"declaration_type": schema.StringAttribute{
 				Required:    true,
 				Optional:    false,
-				Description: "Required. The type of declaration being defined",
+				Description: "Required. The type of declaration being defined, for example com.apple.configuration.passcode.settings. The type and the keys of the declaration are checked during plan against the schemas Apple publishes for Declarative Device Management, types newer than the schemas of the provider are accepted with a warning.",
+				Validators: []validator.String{
+					declarationTypeValidator{},
+				},
 			},
 			"id": schema.StringAttribute{
 				Computed: true,
@@ -105,12 +113,54 @@ func (r *customDeclarationResource) Schema(_ context.Context, _ resource.SchemaR
 			},
 			"activation_predicate": schema.StringAttribute{
 				Optional:    true,
-				Description: "Optional. A predicate format string as Apple's Predicate Programming describes. The activation only installs when the predicate evaluates to true or if it is left blank.",
+				Description: "Optional. A predicate format string as Apple's Predicate Programming describes, for example @status(device.model.family) == \"iPhone\". The activation only installs when the predicate evaluates to true or if it is left blank. The syntax is checked during plan.",
+				Validators: []validator.String{
+					predicateValidator{},
+				},
 			},
 		},
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *customDeclarationResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
+// ValidateConfig checks the declaration against the schema of its type.
+func (r *customDeclarationResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config customDeclarationResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.DeclarationType.IsNull() || config.DeclarationType.IsUnknown() || config.Declaration.IsNull() || config.Declaration.IsUnknown() {
+		return
+	}
+
+	// Unknown types and declarations which are no JSON object are reported
+	// by the attributes themselves.
+	schema := declarationSchemas()[config.DeclarationType.ValueString()]
+	if schema == nil {
+		return
+	}
+	payload, err := decodeDeclaration(config.Declaration.ValueString())
+	if err != nil {
+		return
+	}
+
+	for _, problem := range declarationPayloadProblems(schema, payload) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("declaration"),
+			"Invalid Declaration",
+			fmt.Sprintf("Attribute declaration does not match the schema of %s: %s.", schema.Payload.DeclarationType, problem),
+		)
+	}
+}
+
 func (r *customDeclarationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Retrieve import ID and save to id attribute
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
@@ -129,10 +179,11 @@ func (r *customDeclarationResource) Create(ctx context.Context, req resource.Cre
 	// Generate API request body from plan
 	declaration, err := r.client.CustomDeclarationCreate(plan.Name.ValueString(), plan.DeclarationType.ValueString(), plan.Declaration.ValueString(), plan.UserScope.ValueBool(), plan.AttributeSupport.ValueBool(), plan.EscapeAttributes.ValueBool(), plan.ActivatetionPredicate.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating declaration",
-			"Could not create declration, unexpected error: "+err.Error(),
-		)
+			"Could not create declration",
+			err,
+		))
 		return
 	}
 
@@ -166,19 +217,29 @@ func (r *customDeclarationResource) Read(ctx context.Context, req resource.ReadR
 	//https://a.simplemdm.com/api/v1/profiles/211589
 	declaration, err := r.client.ProfileGet(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		if isNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM custom declaration",
-			"Could not read custom declaration ID "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read custom declaration ID "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
 	declarationStruct, err := r.client.CustomDeclarationDownload(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		if isNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM custom declaration",
-			"Could not read custom profles ID "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read custom profles ID "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -195,10 +256,9 @@ func (r *customDeclarationResource) Read(ctx context.Context, req resource.ReadR
 	var activationPredicate string
 	if val, ok := payloadMap["activation_predicate"].(string); ok {
 		activationPredicate = val
-		delete(payloadMap, "activation_predicate") // Remove it after extraction
 	}
 
-	delete(payloadMap, "declaration_name")
+	stripDeclarationServerKeys(payloadMap)
 
 	cleanedPayloadBytes, err := json.Marshal(payloadMap)
 	//cleanedPayloadBytes, err := json.MarshalIndent(payloadMap, "", "  ")
@@ -212,8 +272,12 @@ func (r *customDeclarationResource) Read(ctx context.Context, req resource.ReadR
 
 	//get it from call line 170
 	state.DeclarationType = types.StringValue(declarationStruct.Type)
-	state.ActivatetionPredicate = types.StringValue(activationPredicate)
-	state.Declaration = types.StringValue(finalPayloadString)
+	if activationPredicate != "" {
+		state.ActivatetionPredicate = types.StringValue(activationPredicate)
+	} else {
+		state.ActivatetionPredicate = types.StringNull()
+	}
+	state.Declaration = newDeclarationJSONValue(finalPayloadString)
 
 	state.Name = types.StringValue(declaration.Data.Attributes.Name)
 	state.UserScope = types.BoolValue(declaration.Data.Attributes.UserScope)
@@ -240,10 +304,11 @@ func (r *customDeclarationResource) Update(ctx context.Context, req resource.Upd
 	// Generate API request body from plan
 	_, err := r.client.CustomDeclarationUpdate(plan.Name.ValueString(), plan.DeclarationType.ValueString(), plan.Declaration.ValueString(), plan.UserScope.ValueBool(), plan.AttributeSupport.ValueBool(), plan.EscapeAttributes.ValueBool(), plan.ID.ValueString(), plan.ActivatetionPredicate.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating declration",
-			"Could not update declration, unexpected error: "+err.Error(),
-		)
+			"Could not update declration",
+			err,
+		))
 		return
 	}
 
@@ -270,11 +335,12 @@ func (r *customDeclarationResource) Delete(ctx context.Context, req resource.Del
 
 	// Delete existing custom declaration
 	err := r.client.CustomDeclarationDelete(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM custom declaration",
-			"Could not delete custom declaration, unexpected error: "+err.Error(),
-		)
+			"Could not delete custom declaration",
+			err,
+		))
 		return
 	}
 }
@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -52,8 +54,8 @@ func TestAccCustomDeclarationResource(t *testing.T) {
 								userscope = false
 								attributesupport = false
 								escapeattributes = false
-								declaration_type = "com.apple.configuration.safari.bookmarks2"
-								activation_predicate = "1234"			
+								declaration_type = "com.apple.configuration.math.settings"
+								activation_predicate = "@status(device.model.family) == \"iPhone\""			
 		  					}
 			`,
 				Check: resource.ComposeAggregateTestCheckFunc(
@@ -63,11 +65,43 @@ func TestAccCustomDeclarationResource(t *testing.T) {
 					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "userscope", "false"),
 					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "attributesupport", "false"),
 					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "escapeattributes", "false"),
-					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "declaration_type", "com.apple.configuration.safari.bookmarks2"),
-					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "activation_predicate", "1234"),
+					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "declaration_type", "com.apple.configuration.math.settings"),
+					resource.TestCheckResourceAttr("simplemdm_customdeclaration.test", "activation_predicate", `@status(device.model.family) == "iPhone"`),
 				),
 			},
 			//Delete testing automatically occurs in TestCase
 		},
 	})
 }
+
+func TestAccCustomDeclarationResourceFormatting(t *testing.T) {
+	config := providerConfig + `
+		resource "simplemdm_customdeclaration" "formatted" {
+			name= "formatted testdeclaration"
+			declaration = file("./testfiles/testdeclaration.json")
+			declaration_type = "com.apple.configuration.safari.bookmarks"
+		  }
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The indented file stays in state although SimpleMDM returns
+			// the declaration compacted, with its own keys added.
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("simplemdm_customdeclaration.formatted", "declaration", func(value string) error {
+						if !strings.HasPrefix(value, "{\n  \"ManagedBookmarks\"") {
+							return fmt.Errorf("expected the declaration as configured, got %s", value)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
@@ -4,32 +4,32 @@ import (
 	"context"
 	"strconv"
 
-	"github.com/DavidKrau/simplemdm-go-client"
-	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &customProfileResource{}
-	_ resource.ResourceWithConfigure   = &customProfileResource{}
-	_ resource.ResourceWithImportState = &customProfileResource{}
+	_ resource.Resource                 = &customProfileResource{}
+	_ resource.ResourceWithConfigure    = &customProfileResource{}
+	_ resource.ResourceWithImportState  = &customProfileResource{}
+	_ resource.ResourceWithUpgradeState = &customProfileResource{}
 )
 
 // profileResourceModel maps the resource schema data.
 type customProfileResourceModel struct {
-	Name                   types.String `tfsdk:"name"`
-	MobileConfig           types.String `tfsdk:"mobileconfig"`
-	UserScope              types.Bool   `tfsdk:"userscope"`
-	AttributeSupport       types.Bool   `tfsdk:"attributesupport"`
-	EscapeAttributes       types.Bool   `tfsdk:"escapeattributes"`
-	ReinstallAfterOSUpdate types.Bool   `tfsdk:"reinstallafterosupdate"`
-	ID                     types.String `tfsdk:"id"`
+	Name                   types.String      `tfsdk:"name"`
+	MobileConfig           mobileconfigValue `tfsdk:"mobileconfig"`
+	UserScope              types.Bool        `tfsdk:"userscope"`
+	AttributeSupport       types.Bool        `tfsdk:"attributesupport"`
+	EscapeAttributes       types.Bool        `tfsdk:"escapeattributes"`
+	ReinstallAfterOSUpdate types.Bool        `tfsdk:"reinstallafterosupdate"`
+	ID                     types.String      `tfsdk:"id"`
 }
 
 // ProfileResource is a helper function to simplify the provider implementation.
@@ -39,7 +39,7 @@ func CustomProfileResource() resource.Resource {
 
 // profileResource is the resource implementation.
 type customProfileResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -48,7 +48,7 @@ func (r *customProfileResource) Configure(_ context.Context, req resource.Config
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -59,6 +59,7 @@ func (r *customProfileResource) Metadata(_ context.Context, req resource.Metadat
 // Schema defines the schema for the resource.
 func (r *customProfileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Custom Profile resource can be used to manage Custom Profile. Can be used together with Device(s), Assignment Group(s) or Device Group(s) and set addition details regarding Custom Profile.",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -67,9 +68,13 @@ func (r *customProfileResource) Schema(_ context.Context, _ resource.SchemaReque
 				Description: "Required. A name for the profile. Example: \"My First profile by terraform\"",
 			},
 			"mobileconfig": schema.StringAttribute{
+				CustomType:  mobileconfigType{},
 				Required:    true,
 				Optional:    false,
-				Description: "Required. Can be string or you can use function 'file' or 'templatefile' to load string from file (see examples folder). Example: mobileconfig = file(\"./profiles/profile.mobileconfig\") or mobileconfig = <<-EOT PROFILE STRING EOT",
+				Description: "Required. Can be string or you can use function 'file' or 'templatefile' to load string from file (see examples folder). Example: mobileconfig = file(\"./profiles/profile.mobileconfig\") or mobileconfig = <<-EOT PROFILE STRING EOT. The profile is checked during plan: it needs PayloadType Configuration, PayloadIdentifier, PayloadUUID and PayloadContent, and every payload a reverse DNS PayloadType, a PayloadIdentifier and a PayloadUUID of its own. Differences in whitespace, key order or serialization between the configured profile and the one SimpleMDM returns are not reported as changes.",
+				Validators: []validator.String{
+					mobileconfigValidator{},
+				},
 			},
 			"id": schema.StringAttribute{
 				Computed: true,
@@ -106,9 +111,19 @@ func (r *customProfileResource) Schema(_ context.Context, _ resource.SchemaReque
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *customProfileResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
+// ImportState imports a custom profile by its ID or by name:<profile name>.
 func (r *customProfileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importByLookup(ctx, req, resp, "custom profile", map[string]importLookup{
+		"name": customProfilesNamed(r.client),
+	})
 }
 
 // Create a new resource
@@ -124,10 +139,11 @@ func (r *customProfileResource) Create(ctx context.Context, req resource.CreateR
 	// Generate API request body from plan
 	Profile, err := r.client.CustomProfileCreate(plan.Name.ValueString(), plan.MobileConfig.ValueString(), plan.UserScope.ValueBool(), plan.AttributeSupport.ValueBool(), plan.EscapeAttributes.ValueBool(), plan.ReinstallAfterOSUpdate.ValueBool())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating profile",
-			"Could not create profile, unexpected error: "+err.Error(),
-		)
+			"Could not create profile",
+			err,
+		))
 		return
 	}
 
@@ -156,14 +172,25 @@ func (r *customProfileResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
+	// Without an ID there is no profile to read back.
+	if state.ID.IsNull() || state.ID.IsUnknown() || state.ID.ValueString() == "" {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
 	// Get refreshed profile values from SimpleMDM
 
 	profile, err := r.client.ProfileGet(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		if isNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM custom profile",
-			"Could not read custom profile ID "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read custom profile ID "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -175,14 +202,19 @@ func (r *customProfileResource) Read(ctx context.Context, req resource.ReadReque
 
 	_, body, err := r.client.CustomProfileSHA(state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		if isNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM custom profile",
-			"Could not read custom profles ID "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read custom profles ID "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
-	state.MobileConfig = types.StringValue(body)
+	state.MobileConfig = newMobileconfigValue(body)
 	//state.FileSHA = types.StringValue(sha)
 	// h := sha256.New()
 	// h.Write([]byte(plan.MobileConfig.ValueString()))
@@ -209,10 +241,11 @@ func (r *customProfileResource) Update(ctx context.Context, req resource.UpdateR
 	// Generate API request body from plan
 	_, err := r.client.CustomProfileUpdate(plan.Name.ValueString(), plan.MobileConfig.ValueString(), plan.UserScope.ValueBool(), plan.AttributeSupport.ValueBool(), plan.EscapeAttributes.ValueBool(), plan.ReinstallAfterOSUpdate.ValueBool(), "", plan.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating profile",
-			"Could not update profile, unexpected error: "+err.Error(),
-		)
+			"Could not update profile",
+			err,
+		))
 		return
 	}
 
@@ -239,11 +272,12 @@ func (r *customProfileResource) Delete(ctx context.Context, req resource.DeleteR
 
 	// Delete existing custom profile
 	err := r.client.CustomProfileDelete(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM custom profile",
-			"Could not delete custom profile, unexpected error: "+err.Error(),
-		)
+			"Could not delete custom profile",
+			err,
+		))
 		return
 	}
 }
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -43,6 +44,13 @@ func TestAccCustomProfileResource(t *testing.T) {
 				// The filesha and  mobileconfig attributes does not exist in SimpleMDM
 				// API, therefore there is no value for it during import.
 			},
+			// Import by name
+			{
+				ResourceName:      "simplemdm_customprofile.test",
+				ImportState:       true,
+				ImportStateId:     "name:testprofile",
+				ImportStateVerify: true,
+			},
 			// Update and Read testing
 			{
 				Config: providerConfig + `
@@ -70,3 +78,85 @@ func TestAccCustomProfileResource(t *testing.T) {
 		},
 	})
 }
+
+func TestAccCustomProfileResourceDeletedOutsideTerraform(t *testing.T) {
+	if testAccFake == nil {
+		t.Skip("deleting objects outside of Terraform needs the offline stand-in server")
+	}
+
+	config := providerConfig + `
+		resource "simplemdm_customprofile" "removed" {
+			name= "removed testprofile"
+			mobileconfig = file("./testfiles/testprofile.mobileconfig")
+		  }
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			// Refresh must drop the deleted profile from state and plan to
+			// create it again instead of failing.
+			{
+				PreConfig: func() {
+					testAccFake.deleteProfileNamed("removed testprofile")
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccCustomProfileResourceIgnoresReserialization(t *testing.T) {
+	if testAccFake == nil {
+		t.Skip("changing objects outside of Terraform needs the offline stand-in server")
+	}
+
+	config := providerConfig + `
+		resource "simplemdm_customprofile" "reserialized" {
+			name= "reserialized testprofile"
+			mobileconfig = file("./testfiles/testprofile.mobileconfig")
+		  }
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			// The same profile with other indentation and key order is no
+			// change.
+			{
+				PreConfig: func() {
+					testAccFake.editProfileNamed("reserialized testprofile", func(profile *fakeProfile) {
+						value, err := decodePlist([]byte(profile.MobileConfig))
+						if err != nil {
+							t.Fatal(err)
+						}
+						reserialized, err := encodePlistXML(value)
+						if err != nil {
+							t.Fatal(err)
+						}
+						profile.MobileConfig = string(reserialized)
+					})
+				},
+				Config:   config,
+				PlanOnly: true,
+			},
+			// A changed value still is.
+			{
+				PreConfig: func() {
+					testAccFake.editProfileNamed("reserialized testprofile", func(profile *fakeProfile) {
+						profile.MobileConfig = strings.Replace(profile.MobileConfig, "<integer>3</integer>", "<integer>4</integer>", 1)
+					})
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/attr/xattr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ basetypes.StringTypable                    = declarationJSONType{}
+	_ basetypes.StringValuableWithSemanticEquals = declarationJSONValue{}
+	_ xattr.ValidateableAttribute                = declarationJSONValue{}
+)
+
+// declarationServerKeys are the keys SimpleMDM adds to the payload of a
+// custom declaration, they are not part of the configured declaration.
+var declarationServerKeys = []string{"activation_predicate", "declaration_name"}
+
+// declarationJSONType is the type of the declaration attribute of custom
+// declarations. Its values are equal when they hold the same JSON object,
+// whatever the formatting and key order, leaving out the keys SimpleMDM
+// injects.
+type declarationJSONType struct {
+	basetypes.StringType
+}
+
+func (t declarationJSONType) String() string {
+	return "declarationJSONType"
+}
+
+func (t declarationJSONType) ValueType(_ context.Context) attr.Value {
+	return declarationJSONValue{}
+}
+
+func (t declarationJSONType) Equal(o attr.Type) bool {
+	other, ok := o.(declarationJSONType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t declarationJSONType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return declarationJSONValue{StringValue: in}, nil
+}
+
+func (t declarationJSONType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return declarationJSONValue{StringValue: stringValue}, nil
+}
+
+// declarationJSONValue is a value of declarationJSONType.
+type declarationJSONValue struct {
+	basetypes.StringValue
+}
+
+// newDeclarationJSONValue returns a known declaration value.
+func newDeclarationJSONValue(declaration string) declarationJSONValue {
+	return declarationJSONValue{StringValue: basetypes.NewStringValue(declaration)}
+}
+
+func (v declarationJSONValue) Type(_ context.Context) attr.Type {
+	return declarationJSONType{}
+}
+
+func (v declarationJSONValue) Equal(o attr.Value) bool {
+	other, ok := o.(declarationJSONValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+// ValidateAttribute checks that the declaration is a JSON object.
+func (v declarationJSONValue) ValidateAttribute(_ context.Context, req xattr.ValidateAttributeRequest, resp *xattr.ValidateAttributeResponse) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	if _, err := decodeDeclaration(v.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Declaration",
+			fmt.Sprintf("Attribute %s must be a JSON object: %s", req.Path, err),
+		)
+	}
+}
+
+// StringSemanticEquals reports whether both declarations hold the same JSON
+// object once the keys SimpleMDM injects are removed.
+func (v declarationJSONValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(declarationJSONValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T but got value type %T. Please report this to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	if v.ValueString() == newValue.ValueString() {
+		return true, diags
+	}
+	prior, err := decodeDeclaration(v.ValueString())
+	if err != nil {
+		return false, diags
+	}
+	current, err := decodeDeclaration(newValue.ValueString())
+	if err != nil {
+		return false, diags
+	}
+	stripDeclarationServerKeys(prior)
+	stripDeclarationServerKeys(current)
+	return reflect.DeepEqual(prior, current), diags
+}
+
+// decodeDeclaration parses a declaration payload, which must be a JSON
+// object.
+func decodeDeclaration(declaration string) (map[string]any, error) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(declaration), &payload); err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("got null")
+	}
+	return payload, nil
+}
+
+func stripDeclarationServerKeys(payload map[string]any) {
+	for _, key := range declarationServerKeys {
+		delete(payload, key)
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr/xattr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestDeclarationJSONValueSemanticEquals(t *testing.T) {
+	const declaration = `{
+  "ManagedBookmarks": [
+    {
+      "GroupIdentifier": "Group1",
+      "Title": "Company Bookmarks"
+    }
+  ]
+}`
+
+	tests := map[string]struct {
+		other string
+		equal bool
+	}{
+		"compacted with sorted keys": {
+			other: `{"ManagedBookmarks":[{"Title":"Company Bookmarks","GroupIdentifier":"Group1"}]}`,
+			equal: true,
+		},
+		"keys injected by SimpleMDM": {
+			other: `{"declaration_name":"bookmarks","activation_predicate":"","ManagedBookmarks":[{"GroupIdentifier":"Group1","Title":"Company Bookmarks"}]}`,
+			equal: true,
+		},
+		"changed value": {
+			other: `{"ManagedBookmarks":[{"GroupIdentifier":"Group2","Title":"Company Bookmarks"}]}`,
+			equal: false,
+		},
+		"injected key name in a nested object": {
+			other: `{"ManagedBookmarks":[{"GroupIdentifier":"Group1","Title":"Company Bookmarks","declaration_name":"x"}]}`,
+			equal: false,
+		},
+		"not JSON": {
+			other: `ManagedBookmarks`,
+			equal: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			equal, diags := newDeclarationJSONValue(declaration).StringSemanticEquals(context.Background(), newDeclarationJSONValue(test.other))
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if equal != test.equal {
+				t.Errorf("expected semantic equality %t, got %t", test.equal, equal)
+			}
+		})
+	}
+}
+
+func TestDeclarationJSONValueValidateAttribute(t *testing.T) {
+	tests := map[string]string{
+		`{"Enabled": true}`: "",
+		`{"Enabled": true`:  "unexpected end of JSON input",
+		`["Enabled"]`:       "cannot unmarshal array",
+		`null`:              "got null",
+	}
+
+	for declaration, expect := range tests {
+		resp := &xattr.ValidateAttributeResponse{}
+		newDeclarationJSONValue(declaration).ValidateAttribute(context.Background(), xattr.ValidateAttributeRequest{Path: path.Root("declaration")}, resp)
+		switch {
+		case expect == "" && resp.Diagnostics.HasError():
+			t.Errorf("%s: unexpected error %v", declaration, resp.Diagnostics)
+		case expect != "" && (!resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), expect)):
+			t.Errorf("%s: expected an error containing %q, got %v", declaration, expect, resp.Diagnostics)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"maps"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"gopkg.in/yaml.v3"
+)
+
+// declarationSchemaFiles are the Declarative Device Management schemas, see
+// declarations/README.md.
+//
+//go:embed declarations/*/*.yaml
+var declarationSchemaFiles embed.FS
+
+// declarationSchema is the part of a declaration schema the validation
+// reads.
+type declarationSchema struct {
+	Payload struct {
+		DeclarationType string `yaml:"declarationtype"`
+	} `yaml:"payload"`
+	PayloadKeys []declarationSchemaKey `yaml:"payloadkeys"`
+}
+
+// declarationSchemaKey describes one key of a declaration payload.
+type declarationSchemaKey struct {
+	Key       string                 `yaml:"key"`
+	Type      string                 `yaml:"type"`
+	Presence  string                 `yaml:"presence"`
+	RangeList []any                  `yaml:"rangelist"`
+	SubKeys   []declarationSchemaKey `yaml:"subkeys"`
+}
+
+// declarationSchemaAnyKey is the key of schema entries matching every key.
+const declarationSchemaAnyKey = "ANY"
+
+var (
+	declarationSchemasOnce sync.Once
+	declarationSchemasByID map[string]*declarationSchema
+)
+
+// declarationSchemas returns the embedded schemas by declaration type. The
+// files are part of the binary, failing to parse them is a bug.
+func declarationSchemas() map[string]*declarationSchema {
+	declarationSchemasOnce.Do(func() {
+		declarationSchemasByID = map[string]*declarationSchema{}
+		files, err := declarationSchemaFiles.ReadDir("declarations")
+		if err != nil {
+			panic(err)
+		}
+		for _, directory := range files {
+			entries, err := declarationSchemaFiles.ReadDir(path.Join("declarations", directory.Name()))
+			if err != nil {
+				panic(err)
+			}
+			for _, entry := range entries {
+				name := path.Join("declarations", directory.Name(), entry.Name())
+				content, err := declarationSchemaFiles.ReadFile(name)
+				if err != nil {
+					panic(err)
+				}
+				schema := &declarationSchema{}
+				if err := yaml.Unmarshal(content, schema); err != nil {
+					panic(fmt.Sprintf("%s: %s", name, err))
+				}
+				declarationSchemasByID[schema.Payload.DeclarationType] = schema
+			}
+		}
+	})
+	return declarationSchemasByID
+}
+
+// knownDeclarationTypes returns the declaration types with a schema, sorted.
+func knownDeclarationTypes() []string {
+	types := make([]string, 0, len(declarationSchemas()))
+	for declarationType := range declarationSchemas() {
+		types = append(types, declarationType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// declarationTypeValidator checks that a string attribute names a
+// declaration type. Types close to one with an embedded schema and types
+// outside Apple's declaration namespaces are errors, other types may be newer
+// than the embedded schemas and only get a warning.
+type declarationTypeValidator struct{}
+
+// declarationTypePrefixes are the namespaces of Apple's declaration types.
+var declarationTypePrefixes = []string{
+	"com.apple.configuration.",
+	"com.apple.asset.",
+	"com.apple.activation.",
+	"com.apple.management.",
+}
+
+func (v declarationTypeValidator) Description(_ context.Context) string {
+	return "value must be a Declarative Device Management declaration type such as com.apple.configuration.passcode.settings"
+}
+
+func (v declarationTypeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v declarationTypeValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	declarationType := req.ConfigValue.ValueString()
+	if declarationSchemas()[declarationType] != nil {
+		return
+	}
+	detail := fmt.Sprintf("Attribute %s %s, got: %q.", req.Path, v.Description(ctx), declarationType)
+	if suggestion := closestName(declarationType, knownDeclarationTypes()); suggestion != "" {
+		resp.Diagnostics.AddAttributeError(req.Path, "Unknown Declaration Type", detail+" Did you mean "+suggestion+"?")
+		return
+	}
+	for _, prefix := range declarationTypePrefixes {
+		if strings.HasPrefix(declarationType, prefix) {
+			resp.Diagnostics.AddAttributeWarning(
+				req.Path,
+				"Unchecked Declaration Type",
+				fmt.Sprintf("This provider version has no schema of %s, the declaration payload could not be checked.", declarationType),
+			)
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Unknown Declaration Type", detail)
+}
+
+// declarationPayloadProblems checks a declaration payload against the schema
+// of its type and returns what is wrong, each problem starts with the
+// location of the key in the payload.
+func declarationPayloadProblems(schema *declarationSchema, payload map[string]any) []string {
+	// The keys SimpleMDM adds are not part of the schemas.
+	payload = maps.Clone(payload)
+	stripDeclarationServerKeys(payload)
+
+	var problems []string
+	checkDeclarationDictionary(schema.PayloadKeys, payload, "", schema.Payload.DeclarationType, &problems)
+	return problems
+}
+
+func checkDeclarationDictionary(keys []declarationSchemaKey, dict map[string]any, location, declarationType string, problems *[]string) {
+	known := map[string]*declarationSchemaKey{}
+	var knownNames []string
+	var anyKey *declarationSchemaKey
+	for i := range keys {
+		if keys[i].Key == declarationSchemaAnyKey {
+			anyKey = &keys[i]
+			continue
+		}
+		known[keys[i].Key] = &keys[i]
+		knownNames = append(knownNames, keys[i].Key)
+	}
+
+	for _, key := range sortedDeclarationKeys(dict) {
+		keyLocation := joinPlistLocation(location, key)
+		schemaKey, found := known[key]
+		if !found {
+			schemaKey = anyKey
+		}
+		if schemaKey == nil {
+			problem := fmt.Sprintf("%s is not a key of %s", keyLocation, declarationType)
+			if suggestion := closestName(key, knownNames); suggestion != "" {
+				problem += ", did you mean " + suggestion + "?"
+			}
+			*problems = append(*problems, problem)
+			continue
+		}
+		checkDeclarationValue(schemaKey, dict[key], keyLocation, declarationType, problems)
+	}
+
+	for _, key := range keys {
+		if key.Presence != "required" || key.Key == declarationSchemaAnyKey {
+			continue
+		}
+		if _, found := dict[key.Key]; !found {
+			*problems = append(*problems, fmt.Sprintf("%s is required by %s", joinPlistLocation(location, key.Key), declarationType))
+		}
+	}
+}
+
+func checkDeclarationValue(key *declarationSchemaKey, value any, location, declarationType string, problems *[]string) {
+	// SimpleMDM replaces custom variables like {{serial_number}} before the
+	// device sees the value, it can end up being of any type.
+	if text, ok := value.(string); ok && strings.Contains(text, "{{") && strings.Contains(text, "}}") {
+		return
+	}
+
+	expected := strings.Trim(key.Type, "<>")
+	valid := true
+	switch expected {
+	case "string", "data", "date":
+		_, valid = value.(string)
+	case "boolean":
+		_, valid = value.(bool)
+	case "integer":
+		number, ok := value.(float64)
+		valid = ok && number == math.Trunc(number)
+	case "real":
+		_, valid = value.(float64)
+	case "dictionary":
+		dict, ok := value.(map[string]any)
+		if ok && len(key.SubKeys) > 0 {
+			checkDeclarationDictionary(key.SubKeys, dict, location, declarationType, problems)
+		}
+		valid = ok
+	case "array":
+		array, ok := value.([]any)
+		if ok && len(key.SubKeys) > 0 {
+			for i, element := range array {
+				checkDeclarationValue(&key.SubKeys[0], element, fmt.Sprintf("%s[%d]", location, i), declarationType, problems)
+			}
+		}
+		valid = ok
+	}
+	if !valid {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s, got %s", location, declarationTypeName(expected), describeJSONValue(value)))
+		return
+	}
+
+	if len(key.RangeList) > 0 && !declarationRangeContains(key.RangeList, value) {
+		allowed := make([]string, 0, len(key.RangeList))
+		for _, element := range key.RangeList {
+			allowed = append(allowed, fmt.Sprint(element))
+		}
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %s, got %v", location, strings.Join(allowed, ", "), value))
+	}
+}
+
+func declarationRangeContains(rangeList []any, value any) bool {
+	for _, element := range rangeList {
+		switch element := element.(type) {
+		case int:
+			if number, ok := value.(float64); ok && number == float64(element) {
+				return true
+			}
+		case float64:
+			if number, ok := value.(float64); ok && number == element {
+				return true
+			}
+		default:
+			if element == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedDeclarationKeys(dict map[string]any) []string {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func declarationTypeName(typeName string) string {
+	switch typeName {
+	case "integer", "array":
+		return "an " + typeName
+	case "dictionary":
+		return "an object"
+	case "data":
+		return "base64 encoded data"
+	case "date":
+		return "a date string"
+	}
+	return "a " + typeName
+}
+
+// describeJSONValue names the type of a decoded JSON value in messages.
+func describeJSONValue(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case []any:
+		return "an array"
+	}
+	return "an object"
+}
+
+// closestName suggests the candidate a misspelled name most likely meant,
+// or nothing when none is close.
+func closestName(name string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of two strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
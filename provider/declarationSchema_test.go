@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDeclarationSchemasLoad(t *testing.T) {
+	declarationTypes := knownDeclarationTypes()
+	for _, prefix := range []string{"com.apple.configuration.", "com.apple.asset.", "com.apple.activation.", "com.apple.management."} {
+		found := false
+		for _, declarationType := range declarationTypes {
+			found = found || strings.HasPrefix(declarationType, prefix)
+		}
+		if !found {
+			t.Errorf("expected schemas of %s declarations", prefix)
+		}
+	}
+	for declarationType, schema := range declarationSchemas() {
+		if len(schema.PayloadKeys) == 0 {
+			t.Errorf("%s: expected payload keys", declarationType)
+		}
+	}
+}
+
+func TestDeclarationTypeValidator(t *testing.T) {
+	tests := map[string]struct {
+		err     string
+		warning bool
+	}{
+		"com.apple.configuration.passcode.settings": {},
+		"com.apple.configuration.passcode.setting":  {err: "Did you mean com.apple.configuration.passcode.settings?"},
+		"com.example.configuration":                 {err: `got: "com.example.configuration".`},
+		// Declarations Apple publishes after the embedded schemas.
+		"com.apple.configuration.future.feature": {warning: true},
+	}
+
+	for declarationType, expect := range tests {
+		resp := &validator.StringResponse{}
+		declarationTypeValidator{}.ValidateString(context.Background(), validator.StringRequest{
+			Path:        path.Root("declaration_type"),
+			ConfigValue: types.StringValue(declarationType),
+		}, resp)
+		switch {
+		case expect.err == "" && resp.Diagnostics.HasError():
+			t.Errorf("%s: unexpected error %v", declarationType, resp.Diagnostics)
+		case expect.err != "" && (!resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), expect.err)):
+			t.Errorf("%s: expected an error containing %q, got %v", declarationType, expect.err, resp.Diagnostics)
+		case expect.warning != (resp.Diagnostics.WarningsCount() == 1):
+			t.Errorf("%s: expected warning %t, got %v", declarationType, expect.warning, resp.Diagnostics)
+		}
+	}
+}
+
+func TestDeclarationPayloadProblems(t *testing.T) {
+	tests := map[string]struct {
+		declarationType string
+		payload         string
+		expect          []string
+	}{
+		"valid bookmarks": {
+			declarationType: "com.apple.configuration.safari.bookmarks",
+			payload:         `{"ManagedBookmarks":[{"GroupIdentifier":"Group1","Title":"Company","Bookmarks":[{"Title":"Site","URL":"https://www.example.com"}]}],"declaration_name":"bookmarks"}`,
+		},
+		"misspelled key": {
+			declarationType: "com.apple.configuration.math.settings",
+			payload:         `{"Calculator":{"ScientificMode":{"Enabeld":false}}}`,
+			expect:          []string{"Calculator.ScientificMode.Enabeld is not a key of com.apple.configuration.math.settings, did you mean Enabled?"},
+		},
+		"unknown key": {
+			declarationType: "com.apple.configuration.math.settings",
+			payload:         `{"Spreadsheet":true}`,
+			expect:          []string{"Spreadsheet is not a key of com.apple.configuration.math.settings"},
+		},
+		"wrong types and missing keys": {
+			declarationType: "com.apple.configuration.safari.bookmarks",
+			payload:         `{"ManagedBookmarks":[{"GroupIdentifier":1,"Title":"Company","Bookmarks":[{"URL":"https://www.example.com"}]}]}`,
+			expect: []string{
+				"ManagedBookmarks[0].Bookmarks[0].Title is required by com.apple.configuration.safari.bookmarks",
+				"ManagedBookmarks[0].GroupIdentifier must be a string, got a number",
+			},
+		},
+		"integers": {
+			declarationType: "com.apple.configuration.passcode.settings",
+			payload:         `{"MinimumLength":6.5,"MaximumFailedAttempts":10}`,
+			expect:          []string{"MinimumLength must be an integer, got a number"},
+		},
+		"value outside the range list": {
+			declarationType: "com.apple.configuration.diskmanagement.settings",
+			payload:         `{"Restrictions":{"ExternalStorage":"WriteOnly"}}`,
+			expect:          []string{"Restrictions.ExternalStorage must be one of Allowed, ReadOnly, Disallowed, got WriteOnly"},
+		},
+		"custom variables": {
+			declarationType: "com.apple.configuration.passcode.settings",
+			payload:         `{"MinimumLength":"{{passcode_length}}"}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			payload, err := decodeDeclaration(test.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := declarationPayloadProblems(declarationSchemas()[test.declarationType], payload)
+			if len(problems) != len(test.expect) {
+				t.Fatalf("expected %d problems, got %d: %v", len(test.expect), len(problems), problems)
+			}
+			for i, expect := range test.expect {
+				if problems[i] != expect {
+					t.Errorf("expected problem %d to be %q, got %q", i, expect, problems[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// deviceProfileIndex maps device IDs to the IDs of the profiles directly
+// assigned to them. SimpleMDM only lists the assignments on the profiles, so
+// the index is built from the profile list once and kept until the provider
+// changes something or, with the list cache enabled, the cached list
+// expires. Without the cache it is kept for the rest of the run, refreshing
+// every device mustn't download the list again. Concurrent reads wait for
+// one build of the index. The zero value is ready to use.
+type deviceProfileIndex struct {
+	mu      sync.Mutex
+	current *deviceProfileBuild
+}
+
+type deviceProfileBuild struct {
+	// ready is closed once devices or err is set.
+	ready      chan struct{}
+	devices    map[string][]string
+	err        error
+	generation uint64
+	expires    time.Time
+}
+
+// profilesOfDevice returns the IDs of the profiles directly assigned to the
+// device, sorted. Profiles assigned through groups aren't included.
+func (c *simplemdmClient) profilesOfDevice(ctx context.Context, deviceID string) ([]string, error) {
+	index := &c.deviceProfiles
+	for {
+		index.mu.Lock()
+		build := index.current
+		if build != nil {
+			select {
+			case <-build.ready:
+				if build.err == nil && c.deviceProfilesFresh(build) {
+					index.mu.Unlock()
+					return build.devices[deviceID], nil
+				}
+				// Stale or failed, build it again.
+			default:
+				index.mu.Unlock()
+				// Another read is building the index.
+				select {
+				case <-build.ready:
+					continue
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+		}
+
+		// A write while the list is fetched makes the index stale right away.
+		build = &deviceProfileBuild{ready: make(chan struct{}), generation: c.cacheGeneration()}
+		index.current = build
+		index.mu.Unlock()
+
+		build.devices, build.err = c.buildDeviceProfileIndex(ctx)
+		if c.cache != nil && c.cache.ttl > 0 {
+			build.expires = c.cache.now().Add(c.cache.ttl)
+		}
+		close(build.ready)
+		if build.err != nil {
+			return nil, build.err
+		}
+		return build.devices[deviceID], nil
+	}
+}
+
+// deviceProfilesFresh reports whether the index can still be used.
+func (c *simplemdmClient) deviceProfilesFresh(build *deviceProfileBuild) bool {
+	if build.generation != c.cacheGeneration() {
+		return false
+	}
+	return c.cache == nil || c.cache.ttl <= 0 || c.cache.now().Before(build.expires)
+}
+
+// cacheGeneration returns the generation of the list cache, which changes
+// with every write the provider sends.
+func (c *simplemdmClient) cacheGeneration() uint64 {
+	if c.cache == nil {
+		return 0
+	}
+	return c.cache.Generation()
+}
+
+// buildDeviceProfileIndex maps the devices to the profiles assigned to them.
+// The profile list includes the custom configuration profiles.
+func (c *simplemdmClient) buildDeviceProfileIndex(ctx context.Context) (map[string][]string, error) {
+	profiles, err := listAll[apiProfile](ctx, c, "profiles", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := map[string][]string{}
+	for _, profile := range profiles {
+		for _, device := range profile.Relationships.Devices.Data {
+			id := strconv.Itoa(device.ID)
+			devices[id] = append(devices[id], strconv.Itoa(profile.ID))
+		}
+	}
+	for device := range devices {
+		sort.Strings(devices[device])
+	}
+	return devices, nil
+}
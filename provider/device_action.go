@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/action"
+	"github.com/hashicorp/terraform-plugin-framework/action/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ action.Action              = &deviceAction{}
+	_ action.ActionWithConfigure = &deviceAction{}
+)
+
+// DeviceLockAction locks a device.
+func DeviceLockAction() action.Action {
+	return &deviceAction{
+		name:        "device_lock",
+		command:     "lock",
+		description: "Locks a device. The device shows the message and phone number on its lock screen, macOS devices can only be unlocked with the PIN.",
+		parameters: map[string]schema.Attribute{
+			"message": schema.StringAttribute{
+				Optional:    true,
+				Description: "The message shown on the lock screen.",
+			},
+			"phone_number": schema.StringAttribute{
+				Optional:    true,
+				Description: "The phone number shown on the lock screen.",
+			},
+			"pin": schema.StringAttribute{
+				Optional:    true,
+				Description: "The 6 digit PIN which unlocks the device, required for macOS devices.",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[0-9]{6}$`), "must be 6 digits"),
+				},
+			},
+		},
+	}
+}
+
+// DeviceWipeAction erases a device.
+func DeviceWipeAction() action.Action {
+	return &deviceAction{
+		name:        "device_wipe",
+		command:     "wipe",
+		description: "Erases all content and settings of a device. The device stays in SimpleMDM and can enroll again.",
+		parameters: map[string]schema.Attribute{
+			"obliteration_behavior": schema.StringAttribute{
+				Optional:    true,
+				Description: "How Macs with Apple silicon or a T2 chip fall back to obliterating the device when erasing it fails, one of Default, DoNotObliterate, ObliterateWithWarning or Always.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("Default", "DoNotObliterate", "ObliterateWithWarning", "Always"),
+				},
+			},
+			"preserve_data_plan": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether iOS devices keep their cellular data plan.",
+			},
+		},
+	}
+}
+
+// DeviceRestartAction restarts a device.
+func DeviceRestartAction() action.Action {
+	return &deviceAction{
+		name:        "device_restart",
+		command:     "restart",
+		description: "Restarts a device, supervised devices only.",
+	}
+}
+
+// DeviceShutdownAction shuts a device down.
+func DeviceShutdownAction() action.Action {
+	return &deviceAction{
+		name:        "device_shutdown",
+		command:     "shutdown",
+		description: "Shuts a device down, supervised devices only.",
+	}
+}
+
+// DeviceClearPasscodeAction removes the passcode of a device.
+func DeviceClearPasscodeAction() action.Action {
+	return &deviceAction{
+		name:        "device_clear_passcode",
+		command:     "clear_passcode",
+		description: "Removes the passcode of an iOS device. Profiles requiring a passcode prompt the user to set a new one.",
+	}
+}
+
+// DevicePushAppsAction installs the assigned apps on a device.
+func DevicePushAppsAction() action.Action {
+	return &deviceAction{
+		name:        "device_push_apps",
+		command:     "push_apps",
+		description: "Installs the apps assigned to a device which are missing on it.",
+	}
+}
+
+// deviceAction sends an MDM command to a device. The actions only differ in
+// the command and the parameters sent with it, which have the name of their
+// attribute.
+type deviceAction struct {
+	client      *simplemdmClient
+	name        string
+	command     string
+	description string
+	parameters  map[string]schema.Attribute
+}
+
+// Metadata returns the action type name.
+func (a *deviceAction) Metadata(_ context.Context, req action.MetadataRequest, resp *action.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_" + a.name
+}
+
+// Schema defines the schema for the action.
+func (a *deviceAction) Schema(_ context.Context, _ action.SchemaRequest, resp *action.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"device_id": schema.StringAttribute{
+			Required:    true,
+			Description: "The ID of the device.",
+		},
+	}
+	for name, attribute := range a.parameters {
+		attributes[name] = attribute
+	}
+	resp.Schema = schema.Schema{
+		Description: a.description,
+		Attributes:  attributes,
+	}
+}
+
+// Invoke sends the command to the device.
+func (a *deviceAction) Invoke(ctx context.Context, req action.InvokeRequest, resp *action.InvokeResponse) {
+	var deviceID types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("device_id"), &deviceID)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	form := url.Values{}
+	for name, attribute := range a.parameters {
+		switch attribute.(type) {
+		case schema.StringAttribute:
+			var value types.String
+			resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &value)...)
+			if !value.IsNull() && !value.IsUnknown() {
+				form.Set(name, value.ValueString())
+			}
+		case schema.BoolAttribute:
+			var value types.Bool
+			resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &value)...)
+			if !value.IsNull() && !value.IsUnknown() {
+				form.Set(name, strconv.FormatBool(value.ValueBool()))
+			}
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := a.client.apiPost(ctx, "devices/"+deviceID.ValueString()+"/"+a.command, form)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
+			"Error sending device command",
+			fmt.Sprintf("Could not send the %s command to device %s", a.command, deviceID.ValueString()),
+			err,
+		))
+		return
+	}
+
+	if resp.SendProgress != nil {
+		resp.SendProgress(action.InvokeProgressEvent{
+			Message: fmt.Sprintf("Sent the %s command to device %s, the device runs it when it next checks in.", a.command, deviceID.ValueString()),
+		})
+	}
+}
+
+// Configure adds the provider configured client to the action.
+func (a *deviceAction) Configure(_ context.Context, req action.ConfigureRequest, resp *action.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*simplemdmClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Action Configure Type",
+			fmt.Sprintf("Expected *simplemdmClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	a.client = client
+}
@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDeviceActions(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	server, schemas := testActionServer(t, fake)
+
+	tests := map[string]struct {
+		action   string
+		config   map[string]tftypes.Value
+		command  string
+		body     url.Values
+		progress string
+	}{
+		"lock": {
+			action: "simplemdm_device_lock",
+			config: map[string]tftypes.Value{
+				"device_id":    tftypes.NewValue(tftypes.String, "1601809"),
+				"message":      tftypes.NewValue(tftypes.String, "Return to IT"),
+				"phone_number": tftypes.NewValue(tftypes.String, "+1 555 0100"),
+				"pin":          tftypes.NewValue(tftypes.String, "123456"),
+			},
+			command:  "lock",
+			body:     url.Values{"message": {"Return to IT"}, "phone_number": {"+1 555 0100"}, "pin": {"123456"}},
+			progress: "Sent the lock command to device 1601809, the device runs it when it next checks in.",
+		},
+		"lock without parameters": {
+			action:  "simplemdm_device_lock",
+			config:  map[string]tftypes.Value{"device_id": tftypes.NewValue(tftypes.String, "1601810")},
+			command: "lock",
+			body:    url.Values{},
+		},
+		"wipe": {
+			action: "simplemdm_device_wipe",
+			config: map[string]tftypes.Value{
+				"device_id":             tftypes.NewValue(tftypes.String, "1601809"),
+				"obliteration_behavior": tftypes.NewValue(tftypes.String, "DoNotObliterate"),
+				"preserve_data_plan":    tftypes.NewValue(tftypes.Bool, false),
+			},
+			command: "wipe",
+			body:    url.Values{"obliteration_behavior": {"DoNotObliterate"}, "preserve_data_plan": {"false"}},
+		},
+		"restart": {
+			action:  "simplemdm_device_restart",
+			config:  map[string]tftypes.Value{"device_id": tftypes.NewValue(tftypes.String, "2142348")},
+			command: "restart",
+			body:    url.Values{},
+		},
+		"shutdown": {
+			action:  "simplemdm_device_shutdown",
+			config:  map[string]tftypes.Value{"device_id": tftypes.NewValue(tftypes.String, "1601809")},
+			command: "shutdown",
+			body:    url.Values{},
+		},
+		"clear passcode": {
+			action:  "simplemdm_device_clear_passcode",
+			config:  map[string]tftypes.Value{"device_id": tftypes.NewValue(tftypes.String, "1601809")},
+			command: "clear_passcode",
+			body:    url.Values{},
+		},
+		"push apps": {
+			action:  "simplemdm_device_push_apps",
+			config:  map[string]tftypes.Value{"device_id": tftypes.NewValue(tftypes.String, "1601809")},
+			command: "push_apps",
+			body:    url.Values{},
+		},
+	}
+	tested := map[string]bool{}
+	for _, test := range tests {
+		tested[test.action] = true
+	}
+	for action := range schemas.ActionSchemas {
+		if !tested[action] {
+			t.Errorf("no test for action %s", action)
+		}
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake.deviceCommands = nil
+			config := testActionConfig(t, schemas, test.action, test.config)
+
+			validated, err := server.ValidateActionConfig(context.Background(), &tfprotov6.ValidateActionConfigRequest{ActionType: test.action, Config: config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testNoDiagnostics(t, validated.Diagnostics)
+
+			progress, diagnostics := testInvokeAction(t, server, test.action, config)
+			testNoDiagnostics(t, diagnostics)
+			if test.progress != "" && !reflect.DeepEqual(progress, []string{test.progress}) {
+				t.Errorf("reported progress %q, expected %q", progress, test.progress)
+			}
+
+			if len(fake.deviceCommands) != 1 {
+				t.Fatalf("expected one command, got %d", len(fake.deviceCommands))
+			}
+			command := fake.deviceCommands[0]
+			var expectedID string
+			if err := test.config["device_id"].As(&expectedID); err != nil {
+				t.Fatal(err)
+			}
+			if command.Command != test.command || strconv.Itoa(command.DeviceID) != expectedID {
+				t.Errorf("sent %s to device %d, expected %s to device %s", command.Command, command.DeviceID, test.command, expectedID)
+			}
+			if command.ContentType != "application/x-www-form-urlencoded" {
+				t.Errorf("sent the body as %s", command.ContentType)
+			}
+			if !reflect.DeepEqual(command.Body, test.body) {
+				t.Errorf("sent body %v, expected %v", command.Body, test.body)
+			}
+		})
+	}
+}
+
+func TestDeviceActionErrors(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	server, schemas := testActionServer(t, fake)
+
+	config := testActionConfig(t, schemas, "simplemdm_device_lock", map[string]tftypes.Value{
+		"device_id": tftypes.NewValue(tftypes.String, "1601809"),
+		"pin":       tftypes.NewValue(tftypes.String, "1234"),
+	})
+	validated, err := server.ValidateActionConfig(context.Background(), &tfprotov6.ValidateActionConfigRequest{ActionType: "simplemdm_device_lock", Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validated.Diagnostics) != 1 || validated.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Errorf("expected the 4 digit PIN to be rejected, got %v", validated.Diagnostics)
+	}
+
+	config = testActionConfig(t, schemas, "simplemdm_device_restart", map[string]tftypes.Value{
+		"device_id": tftypes.NewValue(tftypes.String, "999"),
+	})
+	_, diagnostics := testInvokeAction(t, server, "simplemdm_device_restart", config)
+	if len(diagnostics) != 1 || diagnostics[0].Summary != "SimpleMDM Object Not Found" ||
+		!strings.Contains(diagnostics[0].Detail, "Could not send the restart command to device 999") {
+		t.Errorf("expected an error for the unknown device, got %v", diagnostics)
+	}
+	if len(fake.deviceCommands) != 0 {
+		t.Errorf("recorded %d commands", len(fake.deviceCommands))
+	}
+}
+
+// testActionServer returns a provider server configured for the stand-in
+// server and the schemas of the provider.
+func testActionServer(t *testing.T, fake *fakeSimpleMDM) (tfprotov6.ProviderServerWithActions, *tfprotov6.GetProviderSchemaResponse) {
+	t.Helper()
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(&simplemdmProvider{version: "test", transport: fake.transport()})()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, schemas.Diagnostics)
+
+	providerType := schemas.Provider.ValueType().(tftypes.Object)
+	providerConfig := map[string]tftypes.Value{}
+	for name, attributeType := range providerType.AttributeTypes {
+		providerConfig[name] = tftypes.NewValue(attributeType, nil)
+	}
+	providerConfig["host"] = tftypes.NewValue(tftypes.String, fake.host())
+	providerConfig["apikey"] = tftypes.NewValue(tftypes.String, fakeAPIKey)
+	configured, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{
+		Config: testDynamicValue(t, tftypes.NewValue(providerType, providerConfig)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, configured.Diagnostics)
+
+	// The action RPCs aren't part of tfprotov6.ProviderServer yet.
+	actionServer, ok := server.(tfprotov6.ProviderServerWithActions)
+	if !ok {
+		t.Fatal("provider server doesn't serve actions")
+	}
+	return actionServer, schemas
+}
+
+// testActionConfig returns the configuration of the action with the values,
+// all other attributes are null.
+func testActionConfig(t *testing.T, schemas *tfprotov6.GetProviderSchemaResponse, actionType string, values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+	schema := schemas.ActionSchemas[actionType]
+	if schema == nil {
+		t.Fatalf("provider has no action %s", actionType)
+	}
+	objectType := schema.Schema.ValueType().(tftypes.Object)
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+	for name, value := range values {
+		if _, ok := objectType.AttributeTypes[name]; !ok {
+			t.Fatalf("action %s has no attribute %s", actionType, name)
+		}
+		attributes[name] = value
+	}
+	return testDynamicValue(t, tftypes.NewValue(objectType, attributes))
+}
+
+// testInvokeAction invokes the action and returns the progress messages and
+// the diagnostics of the completed event.
+func testInvokeAction(t *testing.T, server tfprotov6.ProviderServerWithActions, actionType string, config *tfprotov6.DynamicValue) ([]string, []*tfprotov6.Diagnostic) {
+	t.Helper()
+	stream, err := server.InvokeAction(context.Background(), &tfprotov6.InvokeActionRequest{ActionType: actionType, Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress []string
+	var diagnostics []*tfprotov6.Diagnostic
+	for event := range stream.Events {
+		switch event := event.Type.(type) {
+		case tfprotov6.ProgressInvokeActionEventType:
+			progress = append(progress, event.Message)
+		case tfprotov6.CompletedInvokeActionEventType:
+			diagnostics = event.Diagnostics
+		}
+	}
+	return progress, diagnostics
+}
@@ -2,12 +2,20 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -19,8 +27,30 @@ var (
 
 // deviceDataSourceModel maps the data source schema data.
 type deviceDataSourceModel struct {
-	ID   types.String `tfsdk:"id"`
-	Name types.String `tfsdk:"name"`
+	ID                      types.String  `tfsdk:"id"`
+	Name                    types.String  `tfsdk:"name"`
+	DeviceName              types.String  `tfsdk:"device_name"`
+	SerialNumber            types.String  `tfsdk:"serial_number"`
+	UDID                    types.String  `tfsdk:"udid"`
+	IMEI                    types.String  `tfsdk:"imei"`
+	Model                   types.String  `tfsdk:"model"`
+	ModelName               types.String  `tfsdk:"model_name"`
+	ModelIdentifier         types.String  `tfsdk:"model_identifier"`
+	OSVersion               types.String  `tfsdk:"os_version"`
+	BuildVersion            types.String  `tfsdk:"build_version"`
+	Status                  types.String  `tfsdk:"status"`
+	EnrolledAt              types.String  `tfsdk:"enrolled_at"`
+	LastSeenAt              types.String  `tfsdk:"last_seen_at"`
+	DEPEnrolled             types.Bool    `tfsdk:"dep_enrolled"`
+	Supervised              types.Bool    `tfsdk:"supervised"`
+	BatteryLevel            types.Int64   `tfsdk:"battery_level"`
+	FileVaultEnabled        types.Bool    `tfsdk:"filevault_enabled"`
+	ActivationLockEnabled   types.Bool    `tfsdk:"activation_lock_enabled"`
+	DeviceCapacity          types.Float64 `tfsdk:"device_capacity"`
+	AvailableDeviceCapacity types.Float64 `tfsdk:"available_device_capacity"`
+	WiFiMAC                 types.String  `tfsdk:"wifi_mac"`
+	DeviceGroupID           types.String  `tfsdk:"device_group_id"`
+	AssignmentGroupIDs      types.Set     `tfsdk:"assignment_group_ids"`
 }
 
 // deviceDataSource is a helper function to simplify the provider implementation.
@@ -30,7 +60,7 @@ func DeviceDataSource() datasource.DataSource {
 
 // deviceDataSource is the data source implementation.
 type deviceDataSource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Metadata returns the data source type name.
@@ -40,39 +70,223 @@ func (d *deviceDataSource) Metadata(_ context.Context, req datasource.MetadataRe
 
 // Schema defines the schema for the data source.
 func (d *deviceDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
-	resp.Schema = schema.Schema{
-		Description: "Device data source can be used together Assignment Group(s) to assign device to these objects.",
-		Attributes: map[string]schema.Attribute{
-			"name": schema.StringAttribute{
-				Computed:    true,
-				Description: "The SimpleMDM name of the device.",
-			},
-			"id": schema.StringAttribute{
-				Required:    true,
-				Description: "The ID of the device.",
+	// The device is looked up by exactly one of these.
+	lookup := []path.Expression{
+		path.MatchRoot("id"),
+		path.MatchRoot("serial_number"),
+		path.MatchRoot("udid"),
+		path.MatchRoot("name"),
+	}
+	attributes := deviceInventoryAttributes()
+	for name, description := range map[string]string{
+		"id":            "The ID of the device.",
+		"serial_number": "The serial number of the device.",
+		"udid":          "The UDID of the device.",
+		"name":          "The SimpleMDM name of the device.",
+	} {
+		attributes[name] = schema.StringAttribute{
+			Optional:    true,
+			Computed:    true,
+			Description: description + " Exactly one of id, serial_number, udid and name has to be set to look up the device.",
+			Validators: []validator.String{
+				stringvalidator.ExactlyOneOf(lookup...),
 			},
+		}
+	}
+	resp.Schema = schema.Schema{
+		Description: "Device data source can be used together Assignment Group(s) to assign device to these objects. The device is looked up by its ID, serial number, UDID or name. It also returns the inventory SimpleMDM collected from the device, attributes SimpleMDM doesn't know for the device are null.",
+		Attributes:  attributes,
+	}
+}
+
+// deviceInventoryAttributes returns the computed attributes of a device and
+// its inventory, all but id.
+func deviceInventoryAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"name": schema.StringAttribute{
+			Computed:    true,
+			Description: "The SimpleMDM name of the device.",
+		},
+		"device_name": schema.StringAttribute{
+			Computed:    true,
+			Description: "The name the device reports for itself.",
+		},
+		"serial_number": schema.StringAttribute{
+			Computed:    true,
+			Description: "The serial number of the device.",
+		},
+		"udid": schema.StringAttribute{
+			Computed:    true,
+			Description: "The UDID of the device.",
+		},
+		"imei": schema.StringAttribute{
+			Computed:    true,
+			Description: "The IMEI of devices with a cellular modem.",
+		},
+		"model": schema.StringAttribute{
+			Computed:    true,
+			Description: "The model number of the device, for example MXK32LL/A.",
+		},
+		"model_name": schema.StringAttribute{
+			Computed:    true,
+			Description: "The marketing name of the model, for example MacBook Pro.",
+		},
+		"model_identifier": schema.StringAttribute{
+			Computed:    true,
+			Description: "The model identifier of the device, for example MacBookPro16,1.",
+		},
+		"os_version": schema.StringAttribute{
+			Computed:    true,
+			Description: "The version of the operating system, for example 14.4.1.",
+		},
+		"build_version": schema.StringAttribute{
+			Computed:    true,
+			Description: "The build of the operating system, for example 23E224.",
+		},
+		"status": schema.StringAttribute{
+			Computed:    true,
+			Description: "The enrollment status of the device, for example enrolled or awaiting enrollment.",
+		},
+		"enrolled_at": schema.StringAttribute{
+			Computed:    true,
+			Description: "When the device enrolled, as RFC 3339 timestamp.",
+		},
+		"last_seen_at": schema.StringAttribute{
+			Computed:    true,
+			Description: "When the device last checked in with SimpleMDM, as RFC 3339 timestamp.",
+		},
+		"dep_enrolled": schema.BoolAttribute{
+			Computed:    true,
+			Description: "Whether the device enrolled through Automated Device Enrollment (DEP).",
+		},
+		"supervised": schema.BoolAttribute{
+			Computed:    true,
+			Description: "Whether the device is supervised.",
+		},
+		"battery_level": schema.Int64Attribute{
+			Computed:    true,
+			Description: "The battery level in percent.",
+		},
+		"filevault_enabled": schema.BoolAttribute{
+			Computed:    true,
+			Description: "Whether FileVault is enabled, macOS only.",
+		},
+		"activation_lock_enabled": schema.BoolAttribute{
+			Computed:    true,
+			Description: "Whether Activation Lock is enabled.",
+		},
+		"device_capacity": schema.Float64Attribute{
+			Computed:    true,
+			Description: "The storage capacity of the device in GB.",
+		},
+		"available_device_capacity": schema.Float64Attribute{
+			Computed:    true,
+			Description: "The free storage of the device in GB.",
+		},
+		"wifi_mac": schema.StringAttribute{
+			Computed:    true,
+			Description: "The Wi-Fi MAC address of the device.",
+		},
+		"device_group_id": schema.StringAttribute{
+			Computed:    true,
+			Description: "The ID of the device group of the device.",
+		},
+		"assignment_group_ids": schema.SetAttribute{
+			Computed:    true,
+			ElementType: types.StringType,
+			Description: "The IDs of the assignment groups the device is a member of.",
 		},
 	}
 }
 
+// setInventory sets the model from the device as the API returns it.
+func (m *deviceDataSourceModel) setInventory(device apiDeviceInventory) {
+	attributes := device.Attributes
+	m.ID = types.StringValue(strconv.Itoa(device.ID))
+	m.Name = types.StringValue(attributes.Name)
+	m.DeviceName = types.StringPointerValue(attributes.DeviceName)
+	m.SerialNumber = types.StringPointerValue(attributes.SerialNumber)
+	m.UDID = types.StringPointerValue(attributes.UniqueIdentifier)
+	m.IMEI = types.StringPointerValue(attributes.IMEI)
+	m.Model = types.StringPointerValue(attributes.Model)
+	m.ModelName = types.StringPointerValue(attributes.ModelName)
+	m.ModelIdentifier = types.StringPointerValue(attributes.ProductName)
+	m.OSVersion = types.StringPointerValue(attributes.OSVersion)
+	m.BuildVersion = types.StringPointerValue(attributes.BuildVersion)
+	m.Status = types.StringPointerValue(attributes.Status)
+	m.EnrolledAt = types.StringPointerValue(attributes.EnrolledAt)
+	m.LastSeenAt = types.StringPointerValue(attributes.LastSeenAt)
+	m.DEPEnrolled = types.BoolPointerValue(attributes.IsDEPEnrollment)
+	m.Supervised = types.BoolPointerValue(attributes.IsSupervised)
+	m.BatteryLevel = batteryLevelValue(attributes.BatteryLevel)
+	m.FileVaultEnabled = types.BoolPointerValue(attributes.FileVaultEnabled)
+	m.ActivationLockEnabled = types.BoolPointerValue(attributes.ActivationLockEnabled)
+	m.DeviceCapacity = types.Float64PointerValue(attributes.DeviceCapacity)
+	m.AvailableDeviceCapacity = types.Float64PointerValue(attributes.AvailableDeviceCapacity)
+	m.WiFiMAC = types.StringPointerValue(attributes.WiFiMAC)
+
+	m.DeviceGroupID = types.StringNull()
+	if group := device.Relationships.DeviceGroup.Data; group != nil {
+		m.DeviceGroupID = types.StringValue(strconv.Itoa(group.ID))
+	}
+	groupIDs := []string{}
+	for _, group := range device.Relationships.Groups.Data {
+		groupIDs = append(groupIDs, strconv.Itoa(group.ID))
+	}
+	m.AssignmentGroupIDs = types.SetValueMust(types.StringType, stringSliceToAttrValues(groupIDs))
+}
+
+// batteryLevelValue converts the battery level SimpleMDM reports, a string
+// like "85%" or a number, to a percentage.
+func batteryLevelValue(raw json.RawMessage) types.Int64 {
+	var level any
+	if err := json.Unmarshal(raw, &level); err != nil {
+		return types.Int64Null()
+	}
+	switch level := level.(type) {
+	case float64:
+		return types.Int64Value(int64(math.Round(level)))
+	case string:
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(level, "%")), 64)
+		if err != nil {
+			return types.Int64Null()
+		}
+		return types.Int64Value(int64(math.Round(percent)))
+	}
+	return types.Int64Null()
+}
+
 // Read refreshes the Terraform state with the latest data.
 func (d *deviceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var state deviceDataSourceModel
 	diags := req.Config.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
-
-	device, err := d.client.DeviceGet(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Read SimpleMDM device",
-			err.Error(),
-		)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	var device struct {
+		Data apiDeviceInventory `json:"data"`
+	}
+	if !state.ID.IsNull() {
+		err := d.client.apiGet(ctx, "devices/"+state.ID.ValueString(), nil, &device)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostic(
+				"Unable to Read SimpleMDM device",
+				"",
+				err,
+			))
+			return
+		}
+	} else {
+		device.Data = d.lookupDevice(ctx, state, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	// Map response body to model
-	state.Name = types.StringValue(device.Data.Attributes.Name)
-	state.ID = types.StringValue(strconv.Itoa(device.Data.ID))
+	state.setInventory(device.Data)
 
 	// Set state
 
@@ -83,17 +297,80 @@ func (d *deviceDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 	}
 }
 
+// lookupDevice finds the device with the serial number, UDID or name of the
+// configuration through the device search. Anything but exactly one match is
+// an error.
+func (d *deviceDataSource) lookupDevice(ctx context.Context, config deviceDataSourceModel, diags *diag.Diagnostics) apiDeviceInventory {
+	var attribute, value string
+	var matches func(device apiDeviceInventory) bool
+	switch {
+	case !config.SerialNumber.IsNull():
+		attribute, value = "serial number", config.SerialNumber.ValueString()
+		matches = func(device apiDeviceInventory) bool {
+			return strings.EqualFold(stringValue(device.Attributes.SerialNumber), value)
+		}
+	case !config.UDID.IsNull():
+		attribute, value = "UDID", config.UDID.ValueString()
+		matches = func(device apiDeviceInventory) bool {
+			return strings.EqualFold(stringValue(device.Attributes.UniqueIdentifier), value)
+		}
+	default:
+		attribute, value = "name", config.Name.ValueString()
+		matches = func(device apiDeviceInventory) bool {
+			return device.Attributes.Name == value
+		}
+	}
+
+	// The search matches parts of several attributes, only exact matches of
+	// the looked up one count.
+	query := url.Values{"search": {value}, "include_awaiting_enrollment": {"true"}}
+	devices, err := listAll[apiDeviceInventory](ctx, d.client, "devices", query)
+	if err != nil {
+		diags.Append(apiErrorDiagnostic(
+			"Unable to Read SimpleMDM device",
+			fmt.Sprintf("Could not search for the device with %s %q", attribute, value),
+			err,
+		))
+		return apiDeviceInventory{}
+	}
+	var found []apiDeviceInventory
+	var ids []string
+	for _, device := range devices {
+		if matches(device) {
+			found = append(found, device)
+			ids = append(ids, strconv.Itoa(device.ID))
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		diags.AddError(
+			"Device Not Found",
+			fmt.Sprintf("No device in SimpleMDM has the %s %q.", attribute, value),
+		)
+	case 1:
+		return found[0]
+	default:
+		sort.Strings(ids)
+		diags.AddError(
+			"Ambiguous Device Lookup",
+			fmt.Sprintf("%d devices in SimpleMDM have the %s %q, their IDs are %s. Look the device up by its ID instead.", len(found), attribute, value, strings.Join(ids, ", ")),
+		)
+	}
+	return apiDeviceInventory{}
+}
+
 // Configure adds the provider configured client to the data source.
 func (d *deviceDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*simplemdm.Client)
+	client, ok := req.ProviderData.(*simplemdmClient)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *simplemdmClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
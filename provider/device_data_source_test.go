@@ -1,8 +1,19 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
 
@@ -16,8 +27,164 @@ func TestAccDeviceDataSource(t *testing.T) {
 				Check: resource.ComposeAggregateTestCheckFunc(
 					// Verify returned values
 					resource.TestCheckResourceAttr("data.simplemdm_device.test", "name", "Test device"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "serial_number", "C02FAKE00001"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "model_identifier", "MacBookPro16,1"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "os_version", "14.4.1"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "status", "enrolled"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "dep_enrolled", "true"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "battery_level", "85"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "activation_lock_enabled", "false"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "device_capacity", "465.63"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "device_group_id", "38041"),
+					resource.TestCheckNoResourceAttr("data.simplemdm_device.test", "imei"),
+					resource.TestCheckResourceAttrSet("data.simplemdm_device.test", "assignment_group_ids.#"),
 				),
 			},
+			// Lookup by serial number
+			{
+				Config: providerConfig + `data "simplemdm_device" "test" {serial_number = "c02fake00002"}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "id", "1601810"),
+					resource.TestCheckResourceAttr("data.simplemdm_device.test", "name", "Test device2"),
+				),
+			},
+			{
+				Config:      providerConfig + `data "simplemdm_device" "test" {}`,
+				ExpectError: regexp.MustCompile(`Invalid Attribute Combination`),
+			},
+			{
+				Config:      providerConfig + `data "simplemdm_device" "test" {name = "Unknown device"}`,
+				ExpectError: regexp.MustCompile(`Device Not Found`),
+			},
 		},
 	})
 }
+
+func TestDeviceDataSourceLookup(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	// A second device named like the first and one whose serial number
+	// contains the serial number of the first.
+	fake.devices[3000000] = &fakeDevice{ID: 3000000, Name: "Test device", DeviceName: "Test device", SerialNumber: "XC02FAKE00001", Status: "enrolled", Attributes: map[string]string{}}
+
+	tests := map[string]struct {
+		config   deviceDataSourceModel
+		expected string
+		err      string
+	}{
+		"id":                 {config: deviceDataSourceModel{ID: types.StringValue("1601810")}, expected: "1601810"},
+		"serial number":      {config: deviceDataSourceModel{SerialNumber: types.StringValue("C02FAKE00001")}, expected: "1601809"},
+		"udid":               {config: deviceDataSourceModel{UDID: types.StringValue("00008030-000a1b2c3d4e5f6a")}, expected: "1601809"},
+		"name":               {config: deviceDataSourceModel{Name: types.StringValue("Test device2")}, expected: "1601810"},
+		"unknown serial":     {config: deviceDataSourceModel{SerialNumber: types.StringValue("C02FAKE0000")}, err: `Device Not Found: No device in SimpleMDM has the serial number "C02FAKE0000".`},
+		"ambiguous name":     {config: deviceDataSourceModel{Name: types.StringValue("Test device")}, err: "Ambiguous Device Lookup: 2 devices in SimpleMDM have the name \"Test device\", their IDs are 1601809, 3000000."},
+		"partial name match": {config: deviceDataSourceModel{Name: types.StringValue("device")}, err: "Device Not Found"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state, err := testReadDevice(fake, test.config)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.ID.ValueString() != test.expected {
+				t.Errorf("found device %s, expected %s", state.ID, test.expected)
+			}
+		})
+	}
+}
+
+func TestDeviceDataSourceLookupValidation(t *testing.T) {
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(&simplemdmProvider{version: "test"})()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectType := schemas.DataSourceSchemas["simplemdm_device"].ValueType().(tftypes.Object)
+
+	tests := map[string]struct {
+		lookup map[string]string
+		valid  bool
+	}{
+		"none":          {lookup: map[string]string{}},
+		"serial number": {lookup: map[string]string{"serial_number": "C02FAKE00001"}, valid: true},
+		"id and name":   {lookup: map[string]string{"id": "1601809", "name": "Test device"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]tftypes.Value{}
+			for attribute, attributeType := range objectType.AttributeTypes {
+				attributes[attribute] = tftypes.NewValue(attributeType, nil)
+			}
+			for attribute, value := range test.lookup {
+				attributes[attribute] = tftypes.NewValue(tftypes.String, value)
+			}
+			validated, err := server.ValidateDataResourceConfig(ctx, &tfprotov6.ValidateDataResourceConfigRequest{
+				TypeName: "simplemdm_device",
+				Config:   testDynamicValue(t, tftypes.NewValue(objectType, attributes)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			invalid := false
+			for _, diagnostic := range validated.Diagnostics {
+				invalid = invalid || diagnostic.Summary == "Invalid Attribute Combination"
+			}
+			if invalid == test.valid {
+				t.Errorf("expected valid %t, got diagnostics %v", test.valid, validated.Diagnostics)
+			}
+		})
+	}
+}
+
+// testReadDevice reads the simplemdm_device data source with the
+// configuration of the model.
+func testReadDevice(fake *fakeSimpleMDM, config deviceDataSourceModel) (deviceDataSourceModel, error) {
+	ctx := context.Background()
+	dataSource := &deviceDataSource{client: fake.client()}
+	var schemaResp datasource.SchemaResponse
+	dataSource.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+
+	configState := tfsdk.State{Schema: schemaResp.Schema, Raw: nullObject(schemaResp.Schema.Type().TerraformType(ctx))}
+	lookup := map[string]types.String{"id": config.ID, "serial_number": config.SerialNumber, "udid": config.UDID, "name": config.Name}
+	for name, value := range lookup {
+		if diags := configState.SetAttribute(ctx, path.Root(name), value); diags.HasError() {
+			return config, diagnosticsError(diags)
+		}
+	}
+	resp := datasource.ReadResponse{State: configState}
+	dataSource.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, &resp)
+	if err := diagnosticsError(resp.Diagnostics); err != nil {
+		return config, err
+	}
+	var state deviceDataSourceModel
+	if diags := resp.State.Get(ctx, &state); diags.HasError() {
+		return state, diagnosticsError(diags)
+	}
+	return state, nil
+}
+
+func TestBatteryLevelValue(t *testing.T) {
+	tests := map[string]types.Int64{
+		`"85%"`:  types.Int64Value(85),
+		`"99.6"`: types.Int64Value(100),
+		`42`:     types.Int64Value(42),
+		`null`:   types.Int64Null(),
+		`"-"`:    types.Int64Null(),
+		``:       types.Int64Null(),
+	}
+	for raw, expected := range tests {
+		if level := batteryLevelValue(json.RawMessage(raw)); !level.Equal(expected) {
+			t.Errorf("battery level %q is %s, expected %s", raw, level, expected)
+		}
+	}
+}
@@ -5,9 +5,7 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
-	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -17,20 +15,22 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &deviceResource{}
-	_ resource.ResourceWithConfigure   = &deviceResource{}
-	_ resource.ResourceWithImportState = &deviceResource{}
+	_ resource.Resource                 = &deviceResource{}
+	_ resource.ResourceWithConfigure    = &deviceResource{}
+	_ resource.ResourceWithImportState  = &deviceResource{}
+	_ resource.ResourceWithUpgradeState = &deviceResource{}
 )
 
 // deviceGroupResourceModel maps the resource schema data.
 type deviceResourceModel struct {
-	Name          types.String `tfsdk:"name"`
-	ID            types.String `tfsdk:"id"`
-	Attributes    types.Map    `tfsdk:"attributes"`
-	Profiles      types.Set    `tfsdk:"profiles"`
-	DeviceGroups  types.Set    `tfsdk:"devicegroups"`
-	DeviceName    types.String `tfsdk:"devicename"`
-	EnrollmentURL types.String `tfsdk:"enrollmenturl"`
+	Name             types.String `tfsdk:"name"`
+	ID               types.String `tfsdk:"id"`
+	Attributes       types.Map    `tfsdk:"attributes"`
+	Profiles         types.Set    `tfsdk:"profiles"`
+	DeviceGroups     types.Set    `tfsdk:"devicegroups"`
+	DeviceName       types.String `tfsdk:"devicename"`
+	EnrollmentURL    types.String `tfsdk:"enrollmenturl"`
+	OnPartialFailure types.String `tfsdk:"on_partial_failure"`
 }
 
 // deviceGroupResource is a helper function to simplify the provider implementation.
@@ -40,7 +40,7 @@ func DeviceResource() resource.Resource {
 
 // deviceGroupResource is the resource implementation.
 type deviceResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -49,7 +49,7 @@ func (r *deviceResource) Configure(_ context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -60,6 +60,7 @@ func (r *deviceResource) Metadata(_ context.Context, req resource.MetadataReques
 // Schema defines the schema for the resource.
 func (r *deviceResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Device resource can be used to manage Device. Can be used together with Custom Profile(s), Attribute(s), Assignment Group(s) or Device Group(s) and set addition details regarding Device.",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -77,7 +78,7 @@ func (r *deviceResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 			"profiles": schema.SetAttribute{
 				ElementType: types.StringType,
 				Optional:    true,
-				Description: "Optional. List of Configuration Profiles (Custom or predefined Profiles and Custom Declarations) assigned to this device.",
+				Description: "Optional. List of Configuration Profiles (Custom or predefined Profiles and Custom Declarations) assigned to this device. Only profiles assigned to the device directly are tracked, not the ones it gets through groups.",
 			},
 			"attributes": schema.MapAttribute{
 				ElementType: types.StringType,
@@ -101,13 +102,24 @@ func (r *deviceResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				},
 				Description: "SimpleMDM enrollment URL is generated when new device is created via API.",
 			},
+			"on_partial_failure": onPartialFailureAttribute("device"),
 		},
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *deviceResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: releases before on_partial_failure saved no value for it.
+		addStateAttribute("on_partial_failure", partialFailureKeep),
+	)
+}
+
+// ImportState imports a device by its ID or by serial:<serial number>.
 func (r *deviceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to state
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importByLookup(ctx, req, resp, "device", map[string]importLookup{
+		"serial": devicesWithSerial(r.client),
+	})
 }
 
 // Create a new resource
@@ -127,38 +139,62 @@ func (r *deviceResource) Create(ctx context.Context, req resource.CreateRequest,
 	// Generate API request body from plan
 	device, err := r.client.DeviceCreate(plan.Name.ValueString(), groups)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating device",
-			"Could not create device, unexpected error: "+err.Error(),
-		)
+			"Could not create device",
+			err,
+		))
 		return
 	}
 
 	plan.ID = types.StringValue(strconv.Itoa(device.Data.ID))
 	plan.EnrollmentURL = types.StringValue(device.Data.Attributes.EnrollmentURL)
 
+	// The device exists from here on, created tracks what was set up so far
+	// so a failure can save it according to on_partial_failure.
+	created := plan
+	created.Attributes = types.MapNull(types.StringType)
+	created.Profiles = types.SetNull(types.StringType)
+	partialFailure := func() {
+		handlePartialCreate(ctx, resp, plan.OnPartialFailure, "device", plan.ID.ValueString(), created, func() error {
+			return r.client.DeviceDelete(plan.ID.ValueString())
+		})
+	}
+
 	//setting attributes
+	attributesSet := map[string]attr.Value{}
 	for attribute, value := range plan.Attributes.Elements() {
 		err := r.client.AttributeSetAttributeForDevice(plan.ID.ValueString(), attribute, strings.Replace(value.String(), "\"", "", 2))
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device attribute",
-				"Could not set attribute value for device, unexpected error: "+err.Error(),
-			)
+				"Could not set attribute value for device",
+				err,
+			))
+			if len(attributesSet) > 0 {
+				created.Attributes, _ = types.MapValue(types.StringType, attributesSet)
+			}
+			partialFailure()
 			return
 		}
+		attributesSet[attribute] = value
 	}
+	created.Attributes = plan.Attributes
 
 	// Assign all profiles in plan
-	for _, profileId := range plan.Profiles.Elements() {
-		err := r.client.ProfileAssignToDevice(strings.Replace(profileId.String(), "\"", "", 2), plan.ID.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error updating device profile assignment",
-				"Could not update device profile assignment, unexpected error: "+err.Error(),
-			)
-			return
-		}
+	failures := r.client.forEach(ctx, setToStrings(plan.Profiles), func(profileID string) error {
+		return r.client.ProfileAssignToDevice(profileID, plan.ID.ValueString())
+	})
+	if len(failures) > 0 {
+		resp.Diagnostics.Append(taskFailuresDiagnostic(
+			"Error updating device profile assignment",
+			"Could not assign profile to device "+plan.ID.ValueString(),
+			len(plan.Profiles.Elements()),
+			failures,
+		))
+		created.Profiles = setWithout(plan.Profiles, failedIDs(failures))
+		partialFailure()
+		return
 	}
 
 	// Map response body to schema and populate Computed attribute values
@@ -186,24 +222,18 @@ func (r *deviceResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	resp.Diagnostics.AddWarning(
-		"Notice about profiles:",
-		"API limitations is currently not allowing terraform provider to get state of the profiles assigned to device."+
-			" This is not issue as long as you are using only terraform provider to manage profiles on the device."+
-			" This will be implemented properly once API will have correct responses and we will be able to load profiles assigned to device via API.",
-	)
-
 	// Get device group value from SimpleMDM
 	device, err := r.client.DeviceGet(state.ID.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM device",
-			"Could not read SimpleMDM device "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM device "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -242,7 +272,27 @@ func (r *deviceResource) Read(ctx context.Context, req resource.ReadRequest, res
 		state.DeviceGroups = groupsElements
 	} else {
 		groupsElements := types.SetNull(types.StringType)
-		state.Profiles = groupsElements
+		state.DeviceGroups = groupsElements
+	}
+
+	// Profiles assigned through groups aren't managed by this resource.
+	profileIDs, err := r.client.profilesOfDevice(ctx, state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
+			"Error Reading SimpleMDM device profiles",
+			"Could not read the profiles assigned to SimpleMDM device "+state.ID.ValueString(),
+			err,
+		))
+		return
+	}
+	if len(profileIDs) > 0 {
+		state.Profiles = types.SetValueMust(types.StringType, stringSliceToAttrValues(profileIDs))
+	} else {
+		state.Profiles = types.SetNull(types.StringType)
+	}
+
+	if state.OnPartialFailure.IsNull() {
+		state.OnPartialFailure = types.StringValue(partialFailureKeep)
 	}
 
 	if device.Data.Attributes.EnrollmentURL == "" {
@@ -273,10 +323,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 	// Generate API request body from plan
 	_, err := r.client.DeviceUpdate(plan.ID.ValueString(), plan.Name.ValueString(), plan.DeviceName.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating device",
-			"Could not update device, unexpected error: "+err.Error(),
-		)
+			"Could not update device",
+			err,
+		))
 		return
 	}
 
@@ -300,10 +351,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 	for _, groupId := range groupsToAdd {
 		err := r.client.AssignmentGroupAssignObject(groupId, plan.ID.ValueString(), "devices")
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device group assignment",
-				"Could not update device group assignment, unexpected error: "+err.Error(),
-			)
+				"Could not update device group assignment",
+				err,
+			))
 			return
 		}
 	}
@@ -312,10 +364,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 	for _, groupId := range groupsToRemove {
 		err := r.client.AssignmentGroupUnAssignObject(groupId, plan.ID.ValueString(), "devices")
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device group assignment",
-				"Could not update device group assignment, unexpected error: "+err.Error(),
-			)
+				"Could not update device group assignment",
+				err,
+			))
 			return
 		}
 	}
@@ -329,10 +382,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 				if planValue != stateValue {
 					err := r.client.AttributeSetAttributeForDevice(plan.ID.ValueString(), planAttribute, strings.Replace(planValue.String(), "\"", "", 2))
 					if err != nil {
-						resp.Diagnostics.AddError(
+						resp.Diagnostics.Append(apiErrorDiagnostic(
 							"Error updating SimpleMDM device attributes value",
-							"Could not update SimpleMDM device attributes value "+plan.ID.ValueString()+": "+err.Error(),
-						)
+							"Could not update SimpleMDM device attributes value "+plan.ID.ValueString(),
+							err,
+						))
 						return
 					}
 				}
@@ -342,10 +396,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 		if !found {
 			err := r.client.AttributeSetAttributeForDevice(plan.ID.ValueString(), planAttribute, strings.Replace(planValue.String(), "\"", "", 2))
 			if err != nil {
-				resp.Diagnostics.AddError(
+				resp.Diagnostics.Append(apiErrorDiagnostic(
 					"Error updating SimpleMDM device attributes value",
-					"Could not update SimpleMDM device attributes value "+plan.ID.ValueString()+": "+err.Error(),
-				)
+					"Could not update SimpleMDM device attributes value "+plan.ID.ValueString(),
+					err,
+				))
 				return
 			}
 		}
@@ -363,10 +418,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 		if !found {
 			err := r.client.AttributeSetAttributeForDevice(plan.ID.ValueString(), stateAttribute, "")
 			if err != nil {
-				resp.Diagnostics.AddError(
+				resp.Diagnostics.Append(apiErrorDiagnostic(
 					"Error updating SimpleMDM device attributes value",
-					"Could not update SimpleMDM device attributes value "+plan.ID.ValueString()+": "+err.Error(),
-				)
+					"Could not update SimpleMDM device attributes value "+plan.ID.ValueString(),
+					err,
+				))
 				return
 			}
 		}
@@ -392,10 +448,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 	for _, profileId := range profilesToAdd {
 		err := r.client.ProfileAssignToDevice(profileId, plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device custom profile assignment",
-				"Could not update device custom profile assignment, unexpected error: "+err.Error(),
-			)
+				"Could not update device custom profile assignment",
+				err,
+			))
 			return
 		}
 	}
@@ -404,10 +461,11 @@ func (r *deviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 	for _, profileId := range profilesToRemove {
 		err := r.client.ProfileUnAssignToDevice(profileId, plan.ID.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostic(
 				"Error updating device custom profile assignment",
-				"Could not update device custom profile assignment, unexpected error: "+err.Error(),
-			)
+				"Could not update device custom profile assignment",
+				err,
+			))
 			return
 		}
 	}
@@ -429,11 +487,12 @@ func (r *deviceResource) Delete(ctx context.Context, req resource.DeleteRequest,
 
 	// Delete existing device
 	err := r.client.DeviceDelete(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM device",
-			"Could not delte device, unexpected error: "+err.Error(),
-		)
+			"Could not delte device",
+			err,
+		))
 		return
 	}
 }
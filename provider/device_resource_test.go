@@ -1,8 +1,14 @@
 package provider
 
 import (
+	"context"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
 
@@ -39,9 +45,6 @@ func TestAccDeviceResource(t *testing.T) {
 				ResourceName:      "simplemdm_device.test",
 				ImportState:       true,
 				ImportStateVerify: true,
-				// The profiles and  customprofiles attributes does not exist in SimpleMDM
-				// API, therefore there is no value for it during import.
-				ImportStateVerifyIgnore: []string{"profiles"},
 			},
 			// Update and Read testing
 			{
@@ -70,3 +73,106 @@ func TestAccDeviceResource(t *testing.T) {
 		},
 	})
 }
+
+func TestDeviceReadsAssignedProfiles(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	fake.profiles[172801].Devices[1601809] = true
+	fake.profiles[172804].Devices[1601809] = true
+	fake.profiles[172805].Devices[1601810] = true
+	// Assigned through a group, not to the device.
+	fake.groups[140188].Devices[1601809] = true
+	fake.groups[140188].Profiles[214709] = true
+
+	client := fake.client()
+	client.cache = newListCache(ctx, client.HTTPClient.Transport, time.Minute)
+	client.HTTPClient.Transport = client.cache
+	devices := &deviceResource{client: client}
+
+	readProfiles := func(deviceID string) []string {
+		t.Helper()
+		state, schema, err := exportRead(ctx, devices, deviceID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var profiles []string
+		diags := tfsdk.State{Schema: schema, Raw: state}.GetAttribute(ctx, path.Root("profiles"), &profiles)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		return profiles
+	}
+
+	if profiles := readProfiles("1601809"); !reflect.DeepEqual(profiles, []string{"172801", "172804"}) {
+		t.Errorf("expected profiles 172801 and 172804, got %v", profiles)
+	}
+	requests := fake.requests
+	if profiles := readProfiles("1601810"); !reflect.DeepEqual(profiles, []string{"172805"}) {
+		t.Errorf("expected profile 172805, got %v", profiles)
+	}
+	if profiles := readProfiles("2142348"); profiles != nil {
+		t.Errorf("expected no profiles, got %v", profiles)
+	}
+	// Only the devices themselves are fetched, the profiles come from the index.
+	if fake.requests != requests+2 {
+		t.Errorf("expected 2 requests for reading two devices, got %d", fake.requests-requests)
+	}
+
+	// Unassigned in the UI, the index is rebuilt after the next write.
+	fake.editProfileNamed("Custom Profile 1", func(profile *fakeProfile) {
+		delete(profile.Devices, 1601809)
+	})
+	if err := client.ProfileAssignToDevice("172802", "1601810"); err != nil {
+		t.Fatal(err)
+	}
+	if profiles := readProfiles("1601809"); !reflect.DeepEqual(profiles, []string{"172801"}) {
+		t.Errorf("expected profile 172801, got %v", profiles)
+	}
+}
+
+func TestDeviceProfileIndexWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	fake.profiles[172801].Devices[1601809] = true
+
+	client := fake.client()
+	client.cache = newListCache(ctx, client.HTTPClient.Transport, 0)
+	client.HTTPClient.Transport = client.cache
+
+	// Concurrent reads share one download of the profile list.
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.profilesOfDevice(ctx, "1601809"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fake.requests != 1 {
+		t.Errorf("expected 1 request for the profile list, got %d", fake.requests)
+	}
+
+	// The index is kept without the cache until the provider writes.
+	profiles, err := client.profilesOfDevice(ctx, "1601809")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"172801"}) || fake.requests != 1 {
+		t.Errorf("expected profile 172801 from the index, got %v after %d requests", profiles, fake.requests)
+	}
+	if err := client.ProfileAssignToDevice("172802", "1601809"); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = client.profilesOfDevice(ctx, "1601809")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"172801", "172802"}) {
+		t.Errorf("expected profiles 172801 and 172802 after the write, got %v", profiles)
+	}
+}
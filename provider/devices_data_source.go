@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &devicesDataSource{}
+	_ datasource.DataSourceWithConfigure = &devicesDataSource{}
+)
+
+// devicesDataSourceModel maps the data source schema data.
+type devicesDataSourceModel struct {
+	Search            types.String                  `tfsdk:"search"`
+	OSVersion         types.String                  `tfsdk:"os_version"`
+	Model             types.String                  `tfsdk:"model"`
+	Status            types.String                  `tfsdk:"status"`
+	AssignmentGroupID types.String                  `tfsdk:"assignment_group_id"`
+	CustomAttribute   *devicesCustomAttributeFilter `tfsdk:"custom_attribute"`
+	Devices           []deviceDataSourceModel       `tfsdk:"devices"`
+	IDs               types.Set                     `tfsdk:"ids"`
+}
+
+// devicesCustomAttributeFilter maps the custom attribute filter.
+type devicesCustomAttributeFilter struct {
+	Name  types.String `tfsdk:"name"`
+	Value types.String `tfsdk:"value"`
+}
+
+// DevicesDataSource is a helper function to simplify the provider implementation.
+func DevicesDataSource() datasource.DataSource {
+	return &devicesDataSource{}
+}
+
+// devicesDataSource is the data source implementation.
+type devicesDataSource struct {
+	client *simplemdmClient
+}
+
+// Metadata returns the data source type name.
+func (d *devicesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_devices"
+}
+
+// Schema defines the schema for the data source.
+func (d *devicesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	deviceAttributes := deviceInventoryAttributes()
+	deviceAttributes["id"] = schema.StringAttribute{
+		Computed:    true,
+		Description: "The ID of the device.",
+	}
+	resp.Schema = schema.Schema{
+		Description: "Devices data source lists the devices of the account, optionally only those matching a search term and filters. The ids can be used for the devices of Assignment Group(s).",
+		Attributes: map[string]schema.Attribute{
+			"search": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only devices whose name, serial number, UDID, IMEI or MAC address contains this term. SimpleMDM does the search.",
+			},
+			"os_version": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only devices running this OS version or a release of it, 14 matches 14.4.1.",
+			},
+			"model": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only devices of this model, compared case-insensitively with the model, model name and model identifier, for example MacBook Pro.",
+			},
+			"status": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only devices with this enrollment status, for example enrolled or awaiting enrollment.",
+			},
+			"assignment_group_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only devices which are members of the assignment group with this ID.",
+			},
+			"custom_attribute": schema.SingleNestedAttribute{
+				Optional:    true,
+				Description: "Only devices with this value of a custom attribute, the default value of the attribute counts for devices which have none set.",
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						Required:    true,
+						Description: "The name of the custom attribute.",
+					},
+					"value": schema.StringAttribute{
+						Required:    true,
+						Description: "The value the device has for the custom attribute.",
+					},
+				},
+			},
+			"devices": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The matching devices, sorted by ID.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: deviceAttributes,
+				},
+			},
+			"ids": schema.SetAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The IDs of the matching devices.",
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *devicesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state devicesDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Devices awaiting enrollment are only listed on request.
+	query := url.Values{"include_awaiting_enrollment": {"true"}}
+	if search := state.Search.ValueString(); search != "" {
+		query.Set("search", search)
+	}
+	devices, err := listAll[apiDeviceInventory](ctx, d.client, "devices", query)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
+			"Unable to Read SimpleMDM devices",
+			"",
+			err,
+		))
+		return
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+	// Map response body to model
+	state.Devices = []deviceDataSourceModel{}
+	ids := []string{}
+	for _, device := range devices {
+		if !state.matches(device) {
+			continue
+		}
+		var model deviceDataSourceModel
+		model.setInventory(device)
+		state.Devices = append(state.Devices, model)
+		ids = append(ids, model.ID.ValueString())
+	}
+	state.IDs = types.SetValueMust(types.StringType, stringSliceToAttrValues(ids))
+
+	// Set state
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// matches reports whether the device passes all filters of the
+// configuration.
+func (m *devicesDataSourceModel) matches(device apiDeviceInventory) bool {
+	attributes := device.Attributes
+	if filter := m.OSVersion.ValueString(); filter != "" {
+		version := stringValue(attributes.OSVersion)
+		if version != filter && !strings.HasPrefix(version, filter+".") {
+			return false
+		}
+	}
+	if filter := m.Model.ValueString(); filter != "" {
+		if !strings.EqualFold(stringValue(attributes.Model), filter) &&
+			!strings.EqualFold(stringValue(attributes.ModelName), filter) &&
+			!strings.EqualFold(stringValue(attributes.ProductName), filter) {
+			return false
+		}
+	}
+	if filter := m.Status.ValueString(); filter != "" && stringValue(attributes.Status) != filter {
+		return false
+	}
+	if filter := m.AssignmentGroupID.ValueString(); filter != "" {
+		member := false
+		for _, group := range device.Relationships.Groups.Data {
+			member = member || strconv.Itoa(group.ID) == filter
+		}
+		if !member {
+			return false
+		}
+	}
+	if filter := m.CustomAttribute; filter != nil {
+		matched := false
+		for _, value := range device.Relationships.CustomAttributeValues.Data {
+			matched = matched || value.ID == filter.Name.ValueString() && value.Attributes.Value == filter.Value.ValueString()
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// stringValue returns the string or "" for nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *devicesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*simplemdmClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *simplemdmClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDevicesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + `data "simplemdm_devices" "test" {search = "C02FAKE0000"}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// Verify the devices found
+					resource.TestCheckResourceAttr("data.simplemdm_devices.test", "ids.#", "3"),
+					resource.TestCheckResourceAttr("data.simplemdm_devices.test", "devices.0.id", "1601809"),
+					resource.TestCheckResourceAttr("data.simplemdm_devices.test", "devices.0.os_version", "14.4.1"),
+				),
+			},
+		},
+	})
+}
+
+func TestDevicesDataSourceFilters(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	// More devices than fit on one page.
+	for id := 3000000; id < 3000150; id++ {
+		fake.devices[id] = &fakeDevice{ID: id, Name: "Fleet device", DeviceName: "Fleet device", SerialNumber: "C02FLEET", Status: "awaiting enrollment", Attributes: map[string]string{}}
+	}
+	fake.devices[1601810].Inventory = fakeObject{"os_version": "14.0", "model_name": "iMac", "unique_identifier": "00008030-00FFFFFFFFFFFFFF"}
+	fake.devices[2142348].Inventory = fakeObject{"os_version": "13.6.1", "model_name": "MacBook Pro"}
+	fake.devices[2142348].Attributes["testAttribute2"] = "set"
+	fake.groups[140189].Devices[1601810] = true
+	fake.groups[140189].Devices[2142348] = true
+
+	tests := map[string]struct {
+		filters  devicesDataSourceModel
+		expected []string
+	}{
+		"search serial": {
+			filters:  devicesDataSourceModel{Search: types.StringValue("C02FAKE")},
+			expected: []string{"1601809", "1601810", "2142348"},
+		},
+		"search udid": {
+			filters:  devicesDataSourceModel{Search: types.StringValue("00ffffff")},
+			expected: []string{"1601810"},
+		},
+		"os version": {
+			filters:  devicesDataSourceModel{OSVersion: types.StringValue("14")},
+			expected: []string{"1601809", "1601810"},
+		},
+		"model": {
+			filters:  devicesDataSourceModel{Model: types.StringValue("macbook pro")},
+			expected: []string{"1601809", "2142348"},
+		},
+		"status": {
+			filters:  devicesDataSourceModel{Search: types.StringValue("Fleet"), Status: types.StringValue("enrolled")},
+			expected: []string{},
+		},
+		"assignment group": {
+			filters:  devicesDataSourceModel{AssignmentGroupID: types.StringValue("140189"), OSVersion: types.StringValue("13")},
+			expected: []string{"2142348"},
+		},
+		"custom attribute": {
+			filters:  devicesDataSourceModel{CustomAttribute: &devicesCustomAttributeFilter{Name: types.StringValue("testAttribute2"), Value: types.StringValue("set")}},
+			expected: []string{"2142348"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := testReadDevices(t, fake, test.filters)
+			ids := []string{}
+			for _, device := range state.Devices {
+				ids = append(ids, device.ID.ValueString())
+			}
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("found devices %v, expected %v", ids, test.expected)
+			}
+			if len(state.IDs.Elements()) != len(ids) {
+				t.Errorf("ids %s don't match the devices %v", state.IDs, ids)
+			}
+		})
+	}
+
+	all := testReadDevices(t, fake, devicesDataSourceModel{})
+	if len(all.Devices) != 153 {
+		t.Errorf("found %d devices, expected all 153", len(all.Devices))
+	}
+}
+
+// testReadDevices reads the simplemdm_devices data source with the filters
+// of the model.
+func testReadDevices(t *testing.T, fake *fakeSimpleMDM, filters devicesDataSourceModel) devicesDataSourceModel {
+	t.Helper()
+	ctx := context.Background()
+	dataSource := &devicesDataSource{client: fake.client()}
+	var schemaResp datasource.SchemaResponse
+	dataSource.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+
+	config := tfsdk.State{Schema: schemaResp.Schema, Raw: nullObject(schemaResp.Schema.Type().TerraformType(ctx))}
+	filters.IDs = types.SetNull(types.StringType)
+	if diags := config.Set(ctx, &filters); diags.HasError() {
+		t.Fatal(diagnosticsError(diags))
+	}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: config.Raw}}
+	dataSource.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config.Raw}}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(diagnosticsError(resp.Diagnostics))
+	}
+	var state devicesDataSourceModel
+	if diags := resp.State.Get(ctx, &state); diags.HasError() {
+		t.Fatal(diagnosticsError(diags))
+	}
+	return state
+}
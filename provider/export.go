@@ -0,0 +1,469 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// exportTypes are the resource types Export writes, with the list endpoint
+// of their objects and the file their configuration goes to.
+var exportTypes = []struct {
+	typeName string
+	endpoint string
+	file     string
+}{
+	{"simplemdm_attribute", "custom_attributes", "attributes.tf"},
+	{"simplemdm_app", "apps", "apps.tf"},
+	{"simplemdm_script", "scripts", "scripts.tf"},
+	{"simplemdm_customprofile", "custom_configuration_profiles", "customprofiles.tf"},
+	{"simplemdm_customdeclaration", "custom_declarations", "customdeclarations.tf"},
+	{"simplemdm_device", "devices", "devices.tf"},
+	{"simplemdm_assignmentgroup", "assignment_groups", "assignmentgroups.tf"},
+}
+
+// exportFiles are the attributes Export saves to a file of their own and
+// loads with file(), by resource type.
+var exportFiles = map[string]struct {
+	attribute string
+	dir       string
+	extension string
+}{
+	"simplemdm_customprofile":     {"mobileconfig", "profiles", ".mobileconfig"},
+	"simplemdm_customdeclaration": {"declaration", "declarations", ".json"},
+	"simplemdm_script":            {"scriptfile", "scripts", ".sh"},
+}
+
+// exportReferences are the attributes holding IDs of other objects, by
+// resource type. IDs of exported objects are replaced by a reference to
+// their resource, nested attributes are separated by a dot. The groups of a
+// device stay IDs, referencing the groups as well as the devices of the
+// groups would make the resources depend on each other.
+var exportReferences = map[string]map[string][]string{
+	"simplemdm_assignmentgroup": {
+		"apps.app_id": {"simplemdm_app"},
+		"devices":     {"simplemdm_device"},
+		"profiles":    {"simplemdm_customprofile", "simplemdm_customdeclaration"},
+	},
+	"simplemdm_device": {
+		"profiles": {"simplemdm_customprofile", "simplemdm_customdeclaration"},
+	},
+}
+
+// exportListed is an object of a list response, export only needs its ID
+// and name. Custom attributes have their name as ID, all others a number.
+type exportListed struct {
+	ID         json.RawMessage `json:"id"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+}
+
+// exportedObject is an object read for export.
+type exportedObject struct {
+	typeName string
+	label    string
+	id       string
+	schema   schema.Schema
+	state    tftypes.Value
+}
+
+// exportLabelPattern matches what can't be part of a resource name.
+var exportLabelPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Export writes the configuration of every assignment group, custom profile,
+// custom declaration, script, custom attribute, app and device of a
+// SimpleMDM account to dir: one .tf file per resource type with a resource
+// and an import block for each object, and the profiles, declarations and
+// scripts in files of their own. IDs of exported objects are replaced by
+// references to their resources. The account is the one the provider would
+// use without configuration, see the SIMPLEMDM_* environment variables.
+// Existing files are overwritten.
+func Export(ctx context.Context, version string, dir string) error {
+	p := &simplemdmProvider{version: version}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+	configureResp := &provider.ConfigureResponse{}
+	p.Configure(ctx, provider.ConfigureRequest{Config: tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    nullObject(schemaResp.Schema.Type().TerraformType(ctx)),
+	}}, configureResp)
+	if err := diagnosticsError(configureResp.Diagnostics); err != nil {
+		return err
+	}
+
+	return exportConfiguration(ctx, configureResp.ResourceData.(*simplemdmClient), dir)
+}
+
+func exportConfiguration(ctx context.Context, client *simplemdmClient, dir string) error {
+	resources := map[string]resource.Resource{}
+	for _, newResource := range (&simplemdmProvider{}).Resources(ctx) {
+		r := newResource()
+		metadataResp := &resource.MetadataResponse{}
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "simplemdm"}, metadataResp)
+		if configurable, ok := r.(resource.ResourceWithConfigure); ok {
+			configurable.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, &resource.ConfigureResponse{})
+		}
+		resources[metadataResp.TypeName] = r
+	}
+
+	// Read everything first, references need the labels of all objects.
+	objects := map[string][]exportedObject{}
+	addresses := map[string]map[string]string{}
+	for _, exportType := range exportTypes {
+		listed, err := listAll[exportListed](ctx, client, exportType.endpoint, nil)
+		if err != nil {
+			return fmt.Errorf("listing %s: %w", exportType.endpoint, err)
+		}
+		sort.Slice(listed, func(i, j int) bool {
+			if listed[i].Attributes.Name != listed[j].Attributes.Name {
+				return listed[i].Attributes.Name < listed[j].Attributes.Name
+			}
+			return exportIDLess(string(listed[i].ID), string(listed[j].ID))
+		})
+
+		labels := map[string]bool{}
+		addresses[exportType.typeName] = map[string]string{}
+		for _, object := range listed {
+			id := strings.Trim(string(object.ID), `"`)
+			state, s, err := exportRead(ctx, resources[exportType.typeName], id)
+			if err != nil {
+				return fmt.Errorf("reading %s %s: %w", exportType.typeName, id, err)
+			}
+			if state.IsNull() {
+				// Deleted since it was listed.
+				continue
+			}
+
+			label := exportLabel(exportType.typeName, object.Attributes.Name, id, labels)
+			objects[exportType.typeName] = append(objects[exportType.typeName], exportedObject{
+				typeName: exportType.typeName,
+				label:    label,
+				id:       id,
+				schema:   s,
+				state:    state,
+			})
+			addresses[exportType.typeName][id] = exportType.typeName + "." + label
+		}
+	}
+
+	for _, exportType := range exportTypes {
+		file := hclwrite.NewEmptyFile()
+		for i, object := range objects[exportType.typeName] {
+			if i > 0 {
+				file.Body().AppendNewline()
+			}
+			if err := exportObject(ctx, file.Body(), object, addresses, dir); err != nil {
+				return fmt.Errorf("writing %s %s: %w", object.typeName, object.id, err)
+			}
+		}
+		if err := writeExportFile(filepath.Join(dir, exportType.file), file.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportRead imports the object with the given ID and returns its state,
+// which is null if the object doesn't exist anymore.
+func exportRead(ctx context.Context, r resource.Resource, id string) (tftypes.Value, schema.Schema, error) {
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	importResp := &resource.ImportStateResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	r.(resource.ResourceWithImportState).ImportState(ctx, resource.ImportStateRequest{ID: id}, importResp)
+	if err := diagnosticsError(importResp.Diagnostics); err != nil {
+		return tftypes.Value{}, schemaResp.Schema, err
+	}
+
+	readResp := &resource.ReadResponse{State: importResp.State}
+	r.Read(ctx, resource.ReadRequest{State: importResp.State}, readResp)
+	if err := diagnosticsError(readResp.Diagnostics); err != nil {
+		return tftypes.Value{}, schemaResp.Schema, err
+	}
+	return readResp.State.Raw, schemaResp.Schema, nil
+}
+
+// exportObject appends the import and the resource block of object to body.
+// Attributes which are only computed, null or have their default value are
+// left out.
+func exportObject(ctx context.Context, body *hclwrite.Body, object exportedObject, addresses map[string]map[string]string, dir string) error {
+	address := hcl.Traversal{
+		hcl.TraverseRoot{Name: object.typeName},
+		hcl.TraverseAttr{Name: object.label},
+	}
+	importBody := body.AppendNewBlock("import", nil).Body()
+	importBody.SetAttributeTraversal("to", address)
+	importBody.SetAttributeValue("id", cty.StringVal(object.id))
+	body.AppendNewline()
+
+	var values map[string]tftypes.Value
+	if err := object.state.As(&values); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resourceBody := body.AppendNewBlock("resource", []string{object.typeName, object.label}).Body()
+	for _, name := range names {
+		value := values[name]
+		attribute := object.schema.Attributes[name]
+		if value.IsNull() || (attribute.IsComputed() && !attribute.IsOptional()) {
+			continue
+		}
+		defaultValue, err := exportDefault(ctx, attribute)
+		if err != nil {
+			return err
+		}
+		if defaultValue != nil && defaultValue.Equal(value) {
+			continue
+		}
+
+		if file, ok := exportFiles[object.typeName]; ok && file.attribute == name {
+			var content string
+			if err := value.As(&content); err != nil {
+				return err
+			}
+			if file.extension == ".json" {
+				var indented bytes.Buffer
+				if json.Indent(&indented, []byte(content), "", "  ") == nil {
+					content = indented.String() + "\n"
+				}
+			}
+			filename := file.dir + "/" + object.label + file.extension
+			if err := writeExportFile(filepath.Join(dir, filepath.FromSlash(filename)), []byte(content)); err != nil {
+				return err
+			}
+			resourceBody.SetAttributeRaw(name, hclwrite.TokensForFunctionCall("file", hclwrite.TokensForValue(cty.StringVal("./"+filename))))
+			continue
+		}
+
+		tokens, err := exportTokens(value, name, exportReferences[object.typeName], addresses)
+		if err != nil {
+			return err
+		}
+		resourceBody.SetAttributeRaw(name, tokens)
+	}
+	return nil
+}
+
+// exportDefault returns the default value of attribute, nil if it has none.
+func exportDefault(ctx context.Context, attribute schema.Attribute) (*tftypes.Value, error) {
+	var value attr.Value
+	switch attribute := attribute.(type) {
+	case schema.BoolAttribute:
+		if attribute.Default == nil {
+			return nil, nil
+		}
+		resp := &defaults.BoolResponse{}
+		attribute.Default.DefaultBool(ctx, defaults.BoolRequest{}, resp)
+		value = resp.PlanValue
+	case schema.Int64Attribute:
+		if attribute.Default == nil {
+			return nil, nil
+		}
+		resp := &defaults.Int64Response{}
+		attribute.Default.DefaultInt64(ctx, defaults.Int64Request{}, resp)
+		value = resp.PlanValue
+	case schema.StringAttribute:
+		if attribute.Default == nil {
+			return nil, nil
+		}
+		resp := &defaults.StringResponse{}
+		attribute.Default.DefaultString(ctx, defaults.StringRequest{}, resp)
+		value = resp.PlanValue
+	default:
+		return nil, nil
+	}
+
+	terraformValue, err := value.ToTerraformValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &terraformValue, nil
+}
+
+// exportTokens returns the HCL expression of value. Strings at the paths of
+// references which are the ID of an exported object become a reference to
+// its id attribute.
+func exportTokens(value tftypes.Value, valuePath string, references map[string][]string, addresses map[string]map[string]string) (hclwrite.Tokens, error) {
+	valueType := value.Type()
+	switch {
+	case valueType.Is(tftypes.String):
+		var s string
+		if err := value.As(&s); err != nil {
+			return nil, err
+		}
+		for _, typeName := range references[valuePath] {
+			if address, ok := addresses[typeName][s]; ok {
+				typeName, label, _ := strings.Cut(address, ".")
+				return hclwrite.TokensForTraversal(hcl.Traversal{
+					hcl.TraverseRoot{Name: typeName},
+					hcl.TraverseAttr{Name: label},
+					hcl.TraverseAttr{Name: "id"},
+				}), nil
+			}
+		}
+		return hclwrite.TokensForValue(cty.StringVal(s)), nil
+
+	case valueType.Is(tftypes.Bool):
+		var b bool
+		if err := value.As(&b); err != nil {
+			return nil, err
+		}
+		return hclwrite.TokensForValue(cty.BoolVal(b)), nil
+
+	case valueType.Is(tftypes.Number):
+		n := new(big.Float)
+		if err := value.As(&n); err != nil {
+			return nil, err
+		}
+		return hclwrite.TokensForValue(cty.NumberVal(n)), nil
+
+	case valueType.Is(tftypes.List{}), valueType.Is(tftypes.Set{}):
+		var elements []tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return nil, err
+		}
+		tuple := make([]hclwrite.Tokens, 0, len(elements))
+		for _, element := range elements {
+			tokens, err := exportTokens(element, valuePath, references, addresses)
+			if err != nil {
+				return nil, err
+			}
+			tuple = append(tuple, tokens)
+		}
+		if valueType.Is(tftypes.Set{}) {
+			// Sets have no order, sort them to keep the output stable.
+			sort.Slice(tuple, func(i, j int) bool {
+				return exportLess(tuple[i], tuple[j])
+			})
+		}
+		return hclwrite.TokensForTuple(tuple), nil
+
+	case valueType.Is(tftypes.Map{}), valueType.Is(tftypes.Object{}):
+		var elements map[string]tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(elements))
+		for key := range elements {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		object := make([]hclwrite.ObjectAttrTokens, 0, len(keys))
+		for _, key := range keys {
+			element := elements[key]
+			if element.IsNull() && valueType.Is(tftypes.Object{}) {
+				continue
+			}
+			elementPath := valuePath + "." + key
+			name := hclwrite.TokensForIdentifier(key)
+			if valueType.Is(tftypes.Map{}) {
+				elementPath = valuePath
+				name = hclwrite.TokensForValue(cty.StringVal(key))
+			}
+			tokens, err := exportTokens(element, elementPath, references, addresses)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, hclwrite.ObjectAttrTokens{Name: name, Value: tokens})
+		}
+		return hclwrite.TokensForObject(object), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %s", valueType)
+}
+
+// exportLess orders expressions with numbers by their value, so ID lists
+// read naturally.
+func exportLess(a, b hclwrite.Tokens) bool {
+	return exportIDLess(string(a.Bytes()), string(b.Bytes()))
+}
+
+// exportIDLess orders IDs, quoted or not, by their value when both are
+// numbers and as text otherwise.
+func exportIDLess(a, b string) bool {
+	aNumber, aErr := strconv.Atoi(strings.Trim(a, `"`))
+	bNumber, bErr := strconv.Atoi(strings.Trim(b, `"`))
+	if aErr == nil && bErr == nil {
+		return aNumber < bNumber
+	}
+	return a < b
+}
+
+// exportLabel returns a resource name for the object named name which isn't
+// used yet, and marks it used. Objects sharing a name get their ID appended.
+func exportLabel(typeName string, name string, id string, used map[string]bool) string {
+	base := strings.Trim(exportLabelPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if base == "" || (base[0] >= '0' && base[0] <= '9') {
+		base = strings.TrimSuffix(strings.TrimPrefix(typeName, "simplemdm_")+"_"+base, "_")
+	}
+
+	label := base
+	if used[label] {
+		label = base + "_" + strings.Trim(exportLabelPattern.ReplaceAllString(strings.ToLower(id), "_"), "_")
+	}
+	for i := 2; used[label]; i++ {
+		label = base + "_" + strconv.Itoa(i)
+	}
+	used[label] = true
+	return label
+}
+
+func writeExportFile(filename string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, content, 0o644)
+}
+
+// nullObject returns a value of objectType with every attribute null.
+func nullObject(objectType tftypes.Type) tftypes.Value {
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.(tftypes.Object).AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+	return tftypes.NewValue(objectType, attributes)
+}
+
+// diagnosticsError returns the errors of diags as one error, nil if there
+// are none.
+func diagnosticsError(diags diag.Diagnostics) error {
+	if !diags.HasError() {
+		return nil
+	}
+	var messages []string
+	for _, diagnostic := range diags.Errors() {
+		messages = append(messages, diagnostic.Summary()+": "+diagnostic.Detail())
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestExportConfiguration(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	group := fake.groups[140188]
+	group.Apps[577575] = fakeGroupApp{DeploymentType: "standard", InstallType: "managed"}
+	group.Devices[1601809] = true
+	group.Profiles[172801] = true
+	group.Profiles[172804] = true
+	group.Profiles[214709] = true
+	fake.groups[3000000] = newFakeGroup(3000000, "Test Group")
+
+	dir := t.TempDir()
+	if err := exportConfiguration(context.Background(), fake.client(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	parser := hclparse.NewParser()
+	references := map[string][]string{}
+	for _, file := range []string{"apps.tf", "assignmentgroups.tf", "attributes.tf", "customdeclarations.tf", "customprofiles.tf", "devices.tf", "scripts.tf"} {
+		parsed, diags := parser.ParseHCLFile(filepath.Join(dir, file))
+		if diags.HasErrors() {
+			t.Errorf("%s: %s", file, diags.Error())
+			continue
+		}
+		for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+			if block.Type != "resource" {
+				continue
+			}
+			address := strings.Join(block.Labels, ".")
+			for _, attribute := range block.Body.Attributes {
+				for _, traversal := range attribute.Expr.Variables() {
+					if len(traversal) < 2 {
+						continue
+					}
+					if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+						references[address] = append(references[address], traversal.RootName()+"."+step.Name)
+					}
+				}
+			}
+		}
+	}
+	// Terraform can't plan resources which depend on each other.
+	if cycle := testReferenceCycle(references); cycle != nil {
+		t.Errorf("exported resources reference each other: %s", strings.Join(cycle, " -> "))
+	}
+
+	tests := map[string][]string{
+		"assignmentgroups.tf": {
+			`import {
+  to = simplemdm_assignmentgroup.test_group
+  id = "140188"
+}
+
+resource "simplemdm_assignmentgroup" "test_group" {
+  apps = [{
+    app_id          = simplemdm_app.simplemdm.id
+    deployment_type = "standard"
+    install_type    = "managed"
+  }]
+  devices  = [simplemdm_device.test_device.id]
+  name     = "Test Group"
+  profiles = ["172801", simplemdm_customdeclaration.testdeclaration.id, simplemdm_customprofile.custom_profile_1.id]
+}`,
+			// Names are made unique with the ID.
+			`resource "simplemdm_assignmentgroup" "test_group_3000000" {`,
+			`resource "simplemdm_assignmentgroup" "test_group_2" {`,
+		},
+		"customprofiles.tf": {
+			`mobileconfig = file("./profiles/custom_profile_1.mobileconfig")`,
+		},
+		"customdeclarations.tf": {
+			`declaration = file("./declarations/testdeclaration.json")`,
+			`declaration_type = "com.apple.configuration.safari.bookmarks"`,
+		},
+		"scripts.tf": {
+			`scriptfile = file("./scripts/test_script.sh")`,
+		},
+		"attributes.tf": {
+			`import {
+  to = simplemdm_attribute.testattribute
+  id = "testAttribute"
+}`,
+			`default_value = "value set"`,
+		},
+		"devices.tf": {
+			`id = "1601809"`,
+			`devicegroups = ["140188"]`,
+		},
+		"apps.tf": {
+			`app_store_id = "1040213658"`,
+		},
+		"profiles/custom_profile_1.mobileconfig": {fakeMobileConfig},
+		"declarations/testdeclaration.json": {`{
+  "ManagedBookmarks": []
+}
+`},
+		"scripts/test_script_2.sh": {"#!/bin/bash\necho \"Test 2\""},
+	}
+	for file, expected := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, text := range expected {
+			// Alignment of = depends on the neighbouring attributes.
+			if !strings.Contains(testSingleSpaced(string(content)), testSingleSpaced(text)) {
+				t.Errorf("%s doesn't contain\n%s\n\ngot\n%s", file, text, content)
+			}
+		}
+	}
+
+	// Defaults and computed attributes are left out.
+	apps, _ := os.ReadFile(filepath.Join(dir, "apps.tf"))
+	for _, text := range []string{"deploy_to", "bundle_id", "name"} {
+		if strings.Contains(string(apps), text) {
+			t.Errorf("apps.tf contains %s:\n%s", text, apps)
+		}
+	}
+}
+
+// testReferenceCycle returns the resources of a cycle of references, nil if
+// there is none.
+func testReferenceCycle(references map[string][]string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(address string) []string
+	visit = func(address string) []string {
+		switch state[address] {
+		case visiting:
+			for i, other := range path {
+				if other == address {
+					return append(append([]string{}, path[i:]...), address)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[address] = visiting
+		path = append(path, address)
+		for _, reference := range references[address] {
+			if cycle := visit(reference); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[address] = visited
+		return nil
+	}
+
+	addresses := make([]string, 0, len(references))
+	for address := range references {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		if cycle := visit(address); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// testSingleSpaced replaces runs of spaces by one.
+func testSingleSpaced(s string) string {
+	return regexp.MustCompile(` +`).ReplaceAllString(s, " ")
+}
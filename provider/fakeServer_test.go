@@ -0,0 +1,1518 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DavidKrau/simplemdm-go-client"
+)
+
+// fakeAPIKey is the API key the stand-in server accepts.
+const fakeAPIKey = "fake-simplemdm-apikey"
+
+// fakeSimpleMDM is an in-process stand-in for the SimpleMDM REST API. It keeps
+// every object in memory so acceptance tests can create, read, update and
+// delete resources without talking to a real SimpleMDM account.
+type fakeSimpleMDM struct {
+	server *httptest.Server
+
+	// throttleEvery makes every n-th request fail with 429 Too Many Requests
+	// the way SimpleMDM rate limits clients, 0 disables throttling.
+	throttleEvery int
+	// faults holds status codes returned, in order, for the next requests
+	// instead of handling them.
+	faults   []int
+	requests int
+
+	mu         sync.Mutex
+	nextID     int
+	apps       map[int]*fakeApp
+	groups     map[int]*fakeGroup
+	profiles   map[int]*fakeProfile
+	attributes map[string]*fakeAttribute
+	devices    map[int]*fakeDevice
+	scripts    map[int]*fakeScript
+	scriptJobs map[int]*fakeScriptJob
+	// deviceCommands records the MDM commands sent to devices.
+	deviceCommands []fakeDeviceCommand
+}
+
+type fakeApp struct {
+	ID         int
+	Name       string
+	BundleID   string
+	AppStoreID int
+	DeployTo   string
+}
+
+type fakeGroupApp struct {
+	DeploymentType string
+	InstallType    string
+}
+
+type fakeGroup struct {
+	ID               int
+	Name             string
+	AutoDeploy       bool
+	Priority         int
+	AppTrackLocation bool
+	Apps             map[int]fakeGroupApp
+	Devices          map[int]bool
+	Profiles         map[int]bool
+	Attributes       map[string]string
+}
+
+// fakeProfile covers predefined profiles, custom configuration profiles and
+// custom declarations, which share one ID space in SimpleMDM.
+type fakeProfile struct {
+	ID                     int
+	Kind                   string
+	Name                   string
+	MobileConfig           string
+	DeclarationType        string
+	Payload                string
+	ActivationPredicate    string
+	UserScope              bool
+	AttributeSupport       bool
+	EscapeAttributes       bool
+	ReinstallAfterOSUpdate bool
+	Devices                map[int]bool
+}
+
+type fakeAttribute struct {
+	Name         string
+	DefaultValue string
+}
+
+type fakeDevice struct {
+	ID            int
+	Name          string
+	DeviceName    string
+	SerialNumber  string
+	Status        string
+	EnrollmentURL string
+	Attributes    map[string]string
+	// Inventory holds the attributes the device reports, in the form the
+	// API returns them.
+	Inventory     fakeObject
+	DeviceGroupID int
+}
+
+type fakeDeviceCommand struct {
+	DeviceID    int
+	Command     string
+	ContentType string
+	Body        url.Values
+}
+
+type fakeScript struct {
+	ID              int
+	Name            string
+	Content         string
+	VariableSupport bool
+	CreatedAt       string
+	UpdatedAt       string
+}
+
+type fakeScriptJob struct {
+	ID                   int
+	ScriptID             int
+	ScriptName           string
+	Content              string
+	DeviceIDs            []string
+	AssignmentGroupIDs   []string
+	CustomAttribute      string
+	CustomAttributeRegex string
+	Status               string
+}
+
+// newFakeSimpleMDM starts a stand-in server seeded with the fixtures the
+// acceptance tests refer to.
+func newFakeSimpleMDM() *fakeSimpleMDM {
+	f := &fakeSimpleMDM{
+		nextID:     9000000,
+		apps:       map[int]*fakeApp{},
+		groups:     map[int]*fakeGroup{},
+		profiles:   map[int]*fakeProfile{},
+		attributes: map[string]*fakeAttribute{},
+		devices:    map[int]*fakeDevice{},
+		scripts:    map[int]*fakeScript{},
+		scriptJobs: map[int]*fakeScriptJob{},
+	}
+	f.seed()
+	f.server = httptest.NewTLSServer(f.routes())
+	return f
+}
+
+// host returns the value to use for the provider host attribute.
+func (f *fakeSimpleMDM) host() string {
+	return strings.TrimPrefix(f.server.URL, "https://")
+}
+
+// transport returns an HTTP transport which trusts the server certificate.
+func (f *fakeSimpleMDM) transport() http.RoundTripper {
+	return f.server.Client().Transport
+}
+
+// client returns a provider client talking to the server, for tests calling
+// resources directly.
+func (f *fakeSimpleMDM) client() *simplemdmClient {
+	apiClient := simplemdm.NewClient(f.host(), fakeAPIKey)
+	apiClient.HTTPClient.Transport = f.transport()
+	return &simplemdmClient{Client: apiClient, apiKey: fakeAPIKey, maxConcurrency: defaultMaxConcurrency}
+}
+
+func (f *fakeSimpleMDM) close() {
+	f.server.Close()
+}
+
+// deleteProfileNamed removes profiles behind Terraform's back, the way an
+// admin deleting them in the SimpleMDM UI would.
+func (f *fakeSimpleMDM) deleteProfileNamed(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, profile := range f.profiles {
+		if profile.Name == name {
+			delete(f.profiles, id)
+		}
+	}
+}
+
+// editProfileNamed changes profiles behind Terraform's back.
+func (f *fakeSimpleMDM) editProfileNamed(name string, edit func(profile *fakeProfile)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, profile := range f.profiles {
+		if profile.Name == name {
+			edit(profile)
+		}
+	}
+}
+
+func (f *fakeSimpleMDM) seed() {
+	f.apps[577575] = &fakeApp{ID: 577575, Name: "SimpleMDM", BundleID: "com.unwiredrev.DeviceLink.public", AppStoreID: 1040213658}
+	f.apps[553192] = &fakeApp{ID: 553192, Name: "1Password 7", BundleID: "com.agilebits.onepassword7", AppStoreID: 1333542190}
+
+	for id, name := range map[int]string{
+		140188:  "Test Group",
+		140189:  "Test Group 2",
+		1538158: "Static Group",
+		1978695: "Static Group 2",
+		2170591: "Static Group 3",
+		2179161: "Script Group",
+	} {
+		f.groups[id] = newFakeGroup(id, name)
+	}
+
+	f.profiles[172801] = &fakeProfile{ID: 172801, Kind: "restrictions", Name: "Restriction test profiles", Devices: map[int]bool{}}
+	f.profiles[172802] = &fakeProfile{ID: 172802, Kind: "login_window", Name: "Log in screen", Devices: map[int]bool{}}
+	f.profiles[172804] = &fakeProfile{ID: 172804, Kind: "custom_configuration_profile", Name: "Custom Profile 1", MobileConfig: fakeMobileConfig, UserScope: true, Devices: map[int]bool{}}
+	f.profiles[172805] = &fakeProfile{ID: 172805, Kind: "custom_configuration_profile", Name: "Custom Profile 2", MobileConfig: fakeMobileConfig, UserScope: true, Devices: map[int]bool{}}
+	f.profiles[214709] = &fakeProfile{ID: 214709, Kind: "custom_declaration", Name: "testdeclaration", DeclarationType: "com.apple.configuration.safari.bookmarks", Payload: `{"ManagedBookmarks":[]}`, UserScope: true, Devices: map[int]bool{}}
+
+	f.attributes["testAttribute"] = &fakeAttribute{Name: "testAttribute", DefaultValue: "value set"}
+	f.attributes["testAttribute2"] = &fakeAttribute{Name: "testAttribute2", DefaultValue: "value2"}
+
+	f.devices[1601809] = &fakeDevice{ID: 1601809, Name: "Test device", DeviceName: "Test device", SerialNumber: "C02FAKE00001", Status: "enrolled", Attributes: map[string]string{}, DeviceGroupID: 38041,
+		Inventory: fakeObject{
+			"unique_identifier":          "00008030-000A1B2C3D4E5F6A",
+			"imei":                       nil,
+			"model":                      "MXK32LL/A",
+			"model_name":                 "MacBook Pro",
+			"product_name":               "MacBookPro16,1",
+			"os_version":                 "14.4.1",
+			"build_version":              "23E224",
+			"enrolled_at":                "2024-01-15T10:20:30.000-07:00",
+			"last_seen_at":               "2024-04-30T08:00:00.000-07:00",
+			"is_dep_enrollment":          true,
+			"is_supervised":              true,
+			"battery_level":              "85%",
+			"filevault_enabled":          true,
+			"is_activation_lock_enabled": false,
+			"device_capacity":            465.63,
+			"available_device_capacity":  212.5,
+			"wifi_mac":                   "a4:83:e7:00:00:01",
+		}}
+	f.devices[1601810] = &fakeDevice{ID: 1601810, Name: "Test device2", DeviceName: "Test device2", SerialNumber: "C02FAKE00002", Status: "enrolled", Attributes: map[string]string{}}
+	f.devices[2142348] = &fakeDevice{ID: 2142348, Name: "Script device", DeviceName: "Script device", SerialNumber: "C02FAKE00003", Status: "enrolled", Attributes: map[string]string{}}
+
+	f.scripts[5727] = &fakeScript{ID: 5727, Name: "Test script", Content: "#!/bin/bash\necho \"Test\"", CreatedAt: fakeTimestamp(), UpdatedAt: fakeTimestamp()}
+	f.scripts[5728] = &fakeScript{ID: 5728, Name: "Test script 2", Content: "#!/bin/bash\necho \"Test 2\"", CreatedAt: fakeTimestamp(), UpdatedAt: fakeTimestamp()}
+}
+
+const fakeMobileConfig = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>PayloadContent</key>
+    <array/>
+    <key>PayloadDisplayName</key>
+    <string>Fake</string>
+    <key>PayloadIdentifier</key>
+    <string>com.example.fake</string>
+    <key>PayloadType</key>
+    <string>Configuration</string>
+    <key>PayloadUUID</key>
+    <string>2d4c8f3a-5a3e-4a43-8f0e-6f7a1b2c3d4e</string>
+    <key>PayloadVersion</key>
+    <integer>1</integer>
+</dict>
+</plist>`
+
+func newFakeGroup(id int, name string) *fakeGroup {
+	return &fakeGroup{
+		ID:               id,
+		Name:             name,
+		AutoDeploy:       true,
+		AppTrackLocation: true,
+		Apps:             map[int]fakeGroupApp{},
+		Devices:          map[int]bool{},
+		Profiles:         map[int]bool{},
+		Attributes:       map[string]string{},
+	}
+}
+
+func fakeTimestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func (f *fakeSimpleMDM) newID() int {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeSimpleMDM) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/apps", f.listApps)
+	mux.HandleFunc("POST /api/v1/apps", f.createApp)
+	mux.HandleFunc("GET /api/v1/apps/{id}", f.getApp)
+	mux.HandleFunc("PATCH /api/v1/apps/{id}", f.updateApp)
+	mux.HandleFunc("DELETE /api/v1/apps/{id}", f.deleteApp)
+
+	mux.HandleFunc("GET /api/v1/assignment_groups", f.listGroups)
+	mux.HandleFunc("POST /api/v1/assignment_groups", f.createGroup)
+	mux.HandleFunc("GET /api/v1/assignment_groups/{id}", f.getGroup)
+	mux.HandleFunc("PATCH /api/v1/assignment_groups/{id}", f.updateGroup)
+	mux.HandleFunc("DELETE /api/v1/assignment_groups/{id}", f.deleteGroup)
+	mux.HandleFunc("POST /api/v1/assignment_groups/{id}/{kind}/{object}", f.assignToGroup)
+	mux.HandleFunc("DELETE /api/v1/assignment_groups/{id}/{kind}/{object}", f.unassignFromGroup)
+	mux.HandleFunc("POST /api/v1/assignment_groups/{id}/{command}", f.groupCommand)
+	for _, prefix := range []string{"/api/v1/assignment_groups", "/api/v1/device_groups"} {
+		mux.HandleFunc("GET "+prefix+"/{id}/custom_attribute_values", f.listGroupAttributeValues)
+		mux.HandleFunc("PUT "+prefix+"/{id}/custom_attribute_values/{name}", f.setGroupAttributeValue)
+	}
+
+	mux.HandleFunc("GET /api/v1/custom_attributes", f.listAttributes)
+	mux.HandleFunc("POST /api/v1/custom_attributes", f.createAttribute)
+	mux.HandleFunc("GET /api/v1/custom_attributes/{name}", f.getAttribute)
+	mux.HandleFunc("PATCH /api/v1/custom_attributes/{name}", f.updateAttribute)
+	mux.HandleFunc("DELETE /api/v1/custom_attributes/{name}", f.deleteAttribute)
+
+	mux.HandleFunc("GET /api/v1/devices", f.listDevices)
+	mux.HandleFunc("POST /api/v1/devices", f.createDevice)
+	mux.HandleFunc("GET /api/v1/devices/{id}", f.getDevice)
+	mux.HandleFunc("PATCH /api/v1/devices/{id}", f.updateDevice)
+	mux.HandleFunc("DELETE /api/v1/devices/{id}", f.deleteDevice)
+	mux.HandleFunc("GET /api/v1/devices/{id}/custom_attribute_values", f.listDeviceAttributeValues)
+	mux.HandleFunc("PUT /api/v1/devices/{id}/custom_attribute_values/{name}", f.setDeviceAttributeValue)
+	mux.HandleFunc("POST /api/v1/devices/{id}/{command}", f.deviceCommand)
+
+	mux.HandleFunc("GET /api/v1/profiles", f.listProfiles(""))
+	mux.HandleFunc("GET /api/v1/profiles/{id}", f.getProfile)
+	mux.HandleFunc("GET /api/v1/custom_configuration_profiles", f.listProfiles("custom_configuration_profile"))
+	mux.HandleFunc("POST /api/v1/custom_configuration_profiles", f.createCustomProfile)
+	mux.HandleFunc("PATCH /api/v1/custom_configuration_profiles/{id}", f.updateCustomProfile)
+	mux.HandleFunc("DELETE /api/v1/custom_configuration_profiles/{id}", f.deleteProfile)
+	mux.HandleFunc("GET /api/v1/custom_configuration_profiles/{id}/download", f.downloadCustomProfile)
+	mux.HandleFunc("GET /api/v1/custom_declarations", f.listProfiles("custom_declaration"))
+	mux.HandleFunc("POST /api/v1/custom_declarations", f.createCustomDeclaration)
+	mux.HandleFunc("PATCH /api/v1/custom_declarations/{id}", f.updateCustomDeclaration)
+	mux.HandleFunc("DELETE /api/v1/custom_declarations/{id}", f.deleteProfile)
+	mux.HandleFunc("GET /api/v1/custom_declarations/{id}/download", f.downloadCustomDeclaration)
+	for _, prefix := range []string{"/api/v1/profiles", "/api/v1/custom_configuration_profiles", "/api/v1/custom_declarations"} {
+		mux.HandleFunc("POST "+prefix+"/{id}/devices/{device}", f.assignProfileToDevice)
+		mux.HandleFunc("DELETE "+prefix+"/{id}/devices/{device}", f.unassignProfileFromDevice)
+	}
+
+	mux.HandleFunc("GET /api/v1/scripts", f.listScripts)
+	mux.HandleFunc("POST /api/v1/scripts", f.createScript)
+	mux.HandleFunc("GET /api/v1/scripts/{id}", f.getScript)
+	mux.HandleFunc("PATCH /api/v1/scripts/{id}", f.updateScript)
+	mux.HandleFunc("DELETE /api/v1/scripts/{id}", f.deleteScript)
+
+	mux.HandleFunc("GET /api/v1/script_jobs", f.listScriptJobs)
+	mux.HandleFunc("POST /api/v1/script_jobs", f.createScriptJob)
+	mux.HandleFunc("GET /api/v1/script_jobs/{id}", f.getScriptJob)
+	mux.HandleFunc("DELETE /api/v1/script_jobs/{id}", f.cancelScriptJob)
+
+	return f.authenticate(mux)
+}
+
+// authenticate rejects requests which do not carry the fake API key the same
+// way SimpleMDM does, using HTTP basic auth with the key as the user name.
+// Authenticated requests are then subject to the configured faults and
+// throttling.
+func (f *fakeSimpleMDM) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		if !ok || user != fakeAPIKey {
+			writeFakeError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests++
+		if len(f.faults) > 0 {
+			status := f.faults[0]
+			f.faults = f.faults[1:]
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			writeFakeError(w, status, http.StatusText(status))
+			return
+		}
+		if f.throttleEvery > 0 && f.requests%f.throttleEvery == 0 {
+			w.Header().Set("Retry-After", "0")
+			writeFakeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ---- helpers
+
+type fakeObject map[string]any
+
+func writeFakeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeFakeError(w http.ResponseWriter, status int, title string) {
+	writeFakeJSON(w, status, fakeObject{"errors": []fakeObject{{"title": title}}})
+}
+
+func writeFakeNotFound(w http.ResponseWriter) {
+	writeFakeError(w, http.StatusNotFound, "object not found")
+}
+
+// fakeForm collects request parameters from the query string, url-encoded
+// and multipart bodies and JSON bodies. Uploaded files are returned as plain
+// values under their field name.
+func fakeForm(r *http.Request) url.Values {
+	values := url.Values{}
+	for k, v := range r.URL.Query() {
+		values[k] = append(values[k], v...)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err == nil {
+			for k, v := range r.MultipartForm.Value {
+				values[k] = append(values[k], v...)
+			}
+			for k, files := range r.MultipartForm.File {
+				for _, fh := range files {
+					file, err := fh.Open()
+					if err != nil {
+						continue
+					}
+					content, _ := io.ReadAll(file)
+					file.Close()
+					values[k] = append(values[k], string(content))
+				}
+			}
+		}
+	case "application/json":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			for k, v := range body {
+				switch typed := v.(type) {
+				case string:
+					values.Add(k, typed)
+				case []any:
+					for _, item := range typed {
+						values.Add(k, fmt.Sprint(item))
+					}
+				default:
+					values.Add(k, fmt.Sprint(typed))
+				}
+			}
+		}
+	default:
+		if err := r.ParseForm(); err == nil {
+			for k, v := range r.PostForm {
+				values[k] = append(values[k], v...)
+			}
+		}
+	}
+	return values
+}
+
+func fakeBool(values url.Values, key string, fallback bool) bool {
+	if !values.Has(key) {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(values.Get(key))
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// fakeIDList accepts repeated parameters as well as comma separated lists.
+func fakeIDList(values url.Values, keys ...string) []string {
+	ids := []string{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			for _, id := range strings.Split(value, ",") {
+				id = strings.Trim(strings.TrimSpace(id), "\"[]")
+				if id != "" {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func fakePathID(r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	return id, err == nil
+}
+
+// writeFakePage applies SimpleMDM style limit/starting_after pagination to
+// an ID ordered list of objects.
+func writeFakePage(w http.ResponseWriter, r *http.Request, ids []int, render func(int) fakeObject) {
+	sort.Ints(ids)
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+	start := 0
+	if after, err := strconv.Atoi(r.URL.Query().Get("starting_after")); err == nil {
+		start = sort.SearchInts(ids, after+1)
+	}
+	end := min(start+limit, len(ids))
+	data := []fakeObject{}
+	for _, id := range ids[start:end] {
+		data = append(data, render(id))
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": data, "has_more": end < len(ids)})
+}
+
+func relationship(kind string, ids []int) fakeObject {
+	sort.Ints(ids)
+	data := []fakeObject{}
+	for _, id := range ids {
+		data = append(data, fakeObject{"type": kind, "id": id})
+	}
+	return fakeObject{"data": data}
+}
+
+func keys(set map[int]bool) []int {
+	ids := []int{}
+	for id := range set {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ---- apps
+
+func (f *fakeSimpleMDM) renderApp(id int) fakeObject {
+	app := f.apps[id]
+	return fakeObject{
+		"type": "app",
+		"id":   app.ID,
+		"attributes": fakeObject{
+			"name":              app.Name,
+			"bundle_identifier": app.BundleID,
+			"itunes_store_id":   app.AppStoreID,
+			"app_type":          "app store",
+			"deploy_to":         app.DeployTo,
+		},
+	}
+}
+
+func (f *fakeSimpleMDM) listApps(w http.ResponseWriter, r *http.Request) {
+	ids := []int{}
+	for id := range f.apps {
+		ids = append(ids, id)
+	}
+	writeFakePage(w, r, ids, f.renderApp)
+}
+
+func (f *fakeSimpleMDM) getApp(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.apps[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderApp(id)})
+}
+
+// fakeCatalog maps App Store IDs to bundle identifiers so an app created by
+// either one reports both, like the real App Store lookup does.
+var fakeCatalog = map[int]string{
+	357852748: "com.example.testapp",
+	586447913: "com.microsoft.Office.Word",
+	586683407: "com.microsoft.Office.Excel",
+}
+
+func (f *fakeSimpleMDM) createApp(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	app := &fakeApp{ID: f.newID(), Name: form.Get("name"), DeployTo: "none"}
+	if storeID := form.Get("app_store_id"); storeID != "" {
+		parsed, err := strconv.Atoi(storeID)
+		if err != nil {
+			writeFakeError(w, http.StatusUnprocessableEntity, "app_store_id is invalid")
+			return
+		}
+		app.AppStoreID = parsed
+		app.BundleID = fakeCatalog[parsed]
+		if app.BundleID == "" {
+			app.BundleID = "com.example.app" + storeID
+		}
+	}
+	if bundleID := form.Get("bundle_id"); bundleID != "" {
+		app.BundleID = bundleID
+		for storeID, catalogBundle := range fakeCatalog {
+			if catalogBundle == bundleID {
+				app.AppStoreID = storeID
+			}
+		}
+	}
+	if app.AppStoreID == 0 && app.BundleID == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "app_store_id or bundle_id is required")
+		return
+	}
+	if app.Name == "" {
+		app.Name = "App " + app.BundleID
+	}
+	f.apps[app.ID] = app
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderApp(app.ID)})
+}
+
+func (f *fakeSimpleMDM) updateApp(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.apps[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	form := fakeForm(r)
+	if name := form.Get("name"); name != "" {
+		f.apps[id].Name = name
+	}
+	if deployTo := form.Get("deploy_to"); deployTo != "" {
+		f.apps[id].DeployTo = deployTo
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderApp(id)})
+}
+
+func (f *fakeSimpleMDM) deleteApp(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.apps[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.apps, id)
+	for _, group := range f.groups {
+		delete(group.Apps, id)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---- assignment groups
+
+func (f *fakeSimpleMDM) renderGroup(id int) fakeObject {
+	group := f.groups[id]
+	apps := []fakeObject{}
+	appIDs := []int{}
+	for appID := range group.Apps {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Ints(appIDs)
+	for _, appID := range appIDs {
+		apps = append(apps, fakeObject{
+			"type":            "app",
+			"id":              appID,
+			"deployment_type": group.Apps[appID].DeploymentType,
+			"install_type":    group.Apps[appID].InstallType,
+		})
+	}
+	return fakeObject{
+		"type": "assignment_group",
+		"id":   group.ID,
+		"attributes": fakeObject{
+			"name":               group.Name,
+			"auto_deploy":        group.AutoDeploy,
+			"priority":           group.Priority,
+			"app_track_location": group.AppTrackLocation,
+			"device_count":       len(group.Devices),
+		},
+		"relationships": fakeObject{
+			"apps":     fakeObject{"data": apps},
+			"devices":  relationship("device", keys(group.Devices)),
+			"profiles": relationship("profile", keys(group.Profiles)),
+		},
+	}
+}
+
+func (f *fakeSimpleMDM) listGroups(w http.ResponseWriter, r *http.Request) {
+	ids := []int{}
+	for id := range f.groups {
+		ids = append(ids, id)
+	}
+	writeFakePage(w, r, ids, f.renderGroup)
+}
+
+func (f *fakeSimpleMDM) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderGroup(id)})
+}
+
+func (f *fakeSimpleMDM) applyGroupForm(w http.ResponseWriter, group *fakeGroup, form url.Values) bool {
+	if name := form.Get("name"); name != "" {
+		group.Name = name
+	}
+	group.AutoDeploy = fakeBool(form, "auto_deploy", group.AutoDeploy)
+	group.AppTrackLocation = fakeBool(form, "app_track_location", group.AppTrackLocation)
+	if form.Has("priority") {
+		priority, err := strconv.Atoi(form.Get("priority"))
+		if err != nil || priority < 0 || priority > 20 {
+			writeFakeError(w, http.StatusUnprocessableEntity, "priority must be between 0 and 20")
+			return false
+		}
+		group.Priority = priority
+	}
+	return true
+}
+
+func (f *fakeSimpleMDM) createGroup(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	if form.Get("name") == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	group := newFakeGroup(f.newID(), "")
+	if !f.applyGroupForm(w, group, form) {
+		return
+	}
+	f.groups[group.ID] = group
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderGroup(group.ID)})
+}
+
+func (f *fakeSimpleMDM) updateGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	if !f.applyGroupForm(w, f.groups[id], fakeForm(r)) {
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderGroup(id)})
+}
+
+func (f *fakeSimpleMDM) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.groups, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeSimpleMDM) assignToGroup(w http.ResponseWriter, r *http.Request) {
+	f.changeGroupMembership(w, r, true)
+}
+
+func (f *fakeSimpleMDM) unassignFromGroup(w http.ResponseWriter, r *http.Request) {
+	f.changeGroupMembership(w, r, false)
+}
+
+func (f *fakeSimpleMDM) changeGroupMembership(w http.ResponseWriter, r *http.Request, assign bool) {
+	id, ok := fakePathID(r, "id")
+	group := f.groups[id]
+	objectID, objectOK := fakePathID(r, "object")
+	if !ok || group == nil || !objectOK {
+		writeFakeNotFound(w)
+		return
+	}
+
+	switch r.PathValue("kind") {
+	case "apps":
+		if f.apps[objectID] == nil {
+			writeFakeNotFound(w)
+			return
+		}
+		if !assign {
+			delete(group.Apps, objectID)
+			break
+		}
+		form := fakeForm(r)
+		app := fakeGroupApp{DeploymentType: form.Get("deployment_type"), InstallType: form.Get("install_type")}
+		if app.DeploymentType == "" {
+			app.DeploymentType = "standard"
+		}
+		if app.InstallType == "" {
+			app.InstallType = "managed"
+		}
+		group.Apps[objectID] = app
+	case "devices":
+		if f.devices[objectID] == nil {
+			writeFakeNotFound(w)
+			return
+		}
+		setMembership(group.Devices, objectID, assign)
+	case "profiles":
+		if f.profiles[objectID] == nil {
+			writeFakeNotFound(w)
+			return
+		}
+		setMembership(group.Profiles, objectID, assign)
+	default:
+		writeFakeNotFound(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setMembership(set map[int]bool, id int, member bool) {
+	if member {
+		set[id] = true
+	} else {
+		delete(set, id)
+	}
+}
+
+func (f *fakeSimpleMDM) groupCommand(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	switch r.PathValue("command") {
+	case "update_apps", "push_apps", "sync_profiles":
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+func (f *fakeSimpleMDM) listGroupAttributeValues(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderAttributeValues(f.groups[id].Attributes, "group")})
+}
+
+// renderAttributeValues lists every custom attribute together with the
+// value in effect and where that value comes from.
+func (f *fakeSimpleMDM) renderAttributeValues(values map[string]string, source string) []fakeObject {
+	names := []string{}
+	for name := range f.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data := []fakeObject{}
+	for _, name := range names {
+		value, valueSource := f.attributes[name].DefaultValue, "default"
+		if set, ok := values[name]; ok {
+			value, valueSource = set, source
+		}
+		data = append(data, fakeObject{
+			"type":       "custom_attribute_value",
+			"id":         name,
+			"attributes": fakeObject{"value": value, "source": valueSource},
+		})
+	}
+	return data
+}
+
+func (f *fakeSimpleMDM) setGroupAttributeValue(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.groups[id] == nil || f.attributes[r.PathValue("name")] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	setAttributeValue(f.groups[id].Attributes, r.PathValue("name"), fakeForm(r).Get("value"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// setAttributeValue stores a value, an empty value clears it the way the
+// SimpleMDM UI does.
+func setAttributeValue(values map[string]string, name, value string) {
+	if value == "" {
+		delete(values, name)
+		return
+	}
+	values[name] = value
+}
+
+// ---- custom attributes
+
+func (f *fakeSimpleMDM) renderAttribute(name string) fakeObject {
+	attribute := f.attributes[name]
+	return fakeObject{
+		"type":       "custom_attribute",
+		"id":         attribute.Name,
+		"attributes": fakeObject{"name": attribute.Name, "default_value": attribute.DefaultValue},
+	}
+}
+
+func (f *fakeSimpleMDM) listAttributes(w http.ResponseWriter, _ *http.Request) {
+	names := []string{}
+	for name := range f.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data := []fakeObject{}
+	for _, name := range names {
+		data = append(data, f.renderAttribute(name))
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": data, "has_more": false})
+}
+
+func (f *fakeSimpleMDM) getAttribute(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if f.attributes[name] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderAttribute(name)})
+}
+
+func (f *fakeSimpleMDM) createAttribute(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	name := form.Get("name")
+	if name == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if f.attributes[name] != nil {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name has already been taken")
+		return
+	}
+	f.attributes[name] = &fakeAttribute{Name: name, DefaultValue: form.Get("default_value")}
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderAttribute(name)})
+}
+
+func (f *fakeSimpleMDM) updateAttribute(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if f.attributes[name] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	f.attributes[name].DefaultValue = fakeForm(r).Get("default_value")
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderAttribute(name)})
+}
+
+func (f *fakeSimpleMDM) deleteAttribute(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if f.attributes[name] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.attributes, name)
+	for _, group := range f.groups {
+		delete(group.Attributes, name)
+	}
+	for _, device := range f.devices {
+		delete(device.Attributes, name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---- devices
+
+func (f *fakeSimpleMDM) renderDevice(id int) fakeObject {
+	device := f.devices[id]
+	groups := []fakeObject{}
+	groupIDs := []int{}
+	for groupID, group := range f.groups {
+		if group.Devices[id] {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	sort.Ints(groupIDs)
+	for _, groupID := range groupIDs {
+		groups = append(groups, fakeObject{"type": "assignment_group", "id": groupID, "group_type": "static"})
+	}
+	attributes := []fakeObject{}
+	for _, value := range f.renderAttributeValues(device.Attributes, "device") {
+		if value["attributes"].(fakeObject)["value"] != "" {
+			attributes = append(attributes, value)
+		}
+	}
+	deviceAttributes := fakeObject{
+		"name":           device.Name,
+		"device_name":    device.DeviceName,
+		"serial_number":  device.SerialNumber,
+		"status":         device.Status,
+		"enrollment_url": device.EnrollmentURL,
+	}
+	for name, value := range device.Inventory {
+		deviceAttributes[name] = value
+	}
+	relationships := fakeObject{
+		"groups":                  fakeObject{"data": groups},
+		"custom_attribute_values": fakeObject{"data": attributes},
+	}
+	if device.DeviceGroupID != 0 {
+		relationships["device_group"] = fakeObject{"data": fakeObject{"type": "device_group", "id": device.DeviceGroupID}}
+	}
+	return fakeObject{
+		"type":          "device",
+		"id":            device.ID,
+		"attributes":    deviceAttributes,
+		"relationships": relationships,
+	}
+}
+
+func (f *fakeSimpleMDM) deviceCommand(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	switch r.PathValue("command") {
+	case "lock", "wipe", "restart", "shutdown", "clear_passcode", "push_apps":
+	default:
+		writeFakeNotFound(w)
+		return
+	}
+	content, _ := io.ReadAll(r.Body)
+	body, err := url.ParseQuery(string(content))
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.deviceCommands = append(f.deviceCommands, fakeDeviceCommand{
+		DeviceID:    id,
+		Command:     r.PathValue("command"),
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        body,
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (f *fakeSimpleMDM) listDevices(w http.ResponseWriter, r *http.Request) {
+	search := strings.ToLower(r.URL.Query().Get("search"))
+	ids := []int{}
+	for id, device := range f.devices {
+		udid, _ := device.Inventory["unique_identifier"].(string)
+		if search == "" ||
+			strings.Contains(strings.ToLower(device.Name), search) ||
+			strings.Contains(strings.ToLower(device.SerialNumber), search) ||
+			strings.Contains(strings.ToLower(udid), search) {
+			ids = append(ids, id)
+		}
+	}
+	writeFakePage(w, r, ids, f.renderDevice)
+}
+
+func (f *fakeSimpleMDM) getDevice(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderDevice(id)})
+}
+
+func (f *fakeSimpleMDM) createDevice(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	if form.Get("name") == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	groupIDs := []int{}
+	for _, rawID := range fakeIDList(form, "group_id", "group_ids", "assignment_group_id", "assignment_group_ids") {
+		groupID, err := strconv.Atoi(rawID)
+		if err != nil || f.groups[groupID] == nil {
+			writeFakeError(w, http.StatusUnprocessableEntity, "group "+rawID+" does not exist")
+			return
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+	device := &fakeDevice{
+		ID:         f.newID(),
+		Name:       form.Get("name"),
+		DeviceName: form.Get("name"),
+		Status:     "awaiting enrollment",
+		Attributes: map[string]string{},
+	}
+	device.EnrollmentURL = fmt.Sprintf("https://a.simplemdm.com/enroll/?c=%d", device.ID)
+	f.devices[device.ID] = device
+	for _, groupID := range groupIDs {
+		f.groups[groupID].Devices[device.ID] = true
+	}
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderDevice(device.ID)})
+}
+
+func (f *fakeSimpleMDM) updateDevice(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	form := fakeForm(r)
+	if name := form.Get("name"); name != "" {
+		f.devices[id].Name = name
+	}
+	if deviceName := form.Get("device_name"); deviceName != "" {
+		f.devices[id].DeviceName = deviceName
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderDevice(id)})
+}
+
+func (f *fakeSimpleMDM) deleteDevice(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.devices, id)
+	for _, group := range f.groups {
+		delete(group.Devices, id)
+	}
+	for _, profile := range f.profiles {
+		delete(profile.Devices, id)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeSimpleMDM) listDeviceAttributeValues(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderAttributeValues(f.devices[id].Attributes, "device")})
+}
+
+func (f *fakeSimpleMDM) setDeviceAttributeValue(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.devices[id] == nil || f.attributes[r.PathValue("name")] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	setAttributeValue(f.devices[id].Attributes, r.PathValue("name"), fakeForm(r).Get("value"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// ---- profiles, custom profiles and custom declarations
+
+func (f *fakeSimpleMDM) renderProfile(id int) fakeObject {
+	profile := f.profiles[id]
+	groupIDs := []int{}
+	for groupID, group := range f.groups {
+		if group.Profiles[id] {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	kind := "profile"
+	if profile.Kind == "custom_configuration_profile" || profile.Kind == "custom_declaration" {
+		kind = profile.Kind
+	}
+	attributes := fakeObject{
+		"name":                      profile.Name,
+		"profile_identifier":        fmt.Sprintf("com.unwiredmdm.%d", profile.ID),
+		"type":                      profile.Kind,
+		"user_scope":                profile.UserScope,
+		"attribute_support":         profile.AttributeSupport,
+		"escape_attributes":         profile.EscapeAttributes,
+		"reinstall_after_os_update": profile.ReinstallAfterOSUpdate,
+		"group_count":               len(groupIDs),
+		"device_count":              len(profile.Devices),
+	}
+	if profile.Kind == "custom_declaration" {
+		attributes["declaration_type"] = profile.DeclarationType
+		attributes["activation_predicate"] = profile.ActivationPredicate
+	}
+	return fakeObject{
+		"type":       kind,
+		"id":         profile.ID,
+		"attributes": attributes,
+		"relationships": fakeObject{
+			"device_groups": relationship("device_group", groupIDs),
+			"devices":       relationship("device", keys(profile.Devices)),
+		},
+	}
+}
+
+func (f *fakeSimpleMDM) listProfiles(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids := []int{}
+		for id, profile := range f.profiles {
+			if kind == "" || profile.Kind == kind {
+				ids = append(ids, id)
+			}
+		}
+		writeFakePage(w, r, ids, f.renderProfile)
+	}
+}
+
+func (f *fakeSimpleMDM) getProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.profiles[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderProfile(id)})
+}
+
+// profileOfKind looks up a profile from the path and makes sure it is served
+// by the endpoint the request was sent to.
+func (f *fakeSimpleMDM) profileOfKind(w http.ResponseWriter, r *http.Request, kind string) *fakeProfile {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.profiles[id] == nil || f.profiles[id].Kind != kind {
+		writeFakeNotFound(w)
+		return nil
+	}
+	return f.profiles[id]
+}
+
+func applyProfileFlags(profile *fakeProfile, form url.Values) {
+	if name := form.Get("name"); name != "" {
+		profile.Name = name
+	}
+	profile.UserScope = fakeBool(form, "user_scope", profile.UserScope)
+	profile.AttributeSupport = fakeBool(form, "attribute_support", profile.AttributeSupport)
+	profile.EscapeAttributes = fakeBool(form, "escape_attributes", profile.EscapeAttributes)
+	profile.ReinstallAfterOSUpdate = fakeBool(form, "reinstall_after_os_update", profile.ReinstallAfterOSUpdate)
+}
+
+func (f *fakeSimpleMDM) createCustomProfile(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	if form.Get("name") == "" || form.Get("mobileconfig") == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name and mobileconfig are required")
+		return
+	}
+	profile := &fakeProfile{ID: f.newID(), Kind: "custom_configuration_profile", UserScope: true, Devices: map[int]bool{}}
+	applyProfileFlags(profile, form)
+	profile.MobileConfig = form.Get("mobileconfig")
+	f.profiles[profile.ID] = profile
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderProfile(profile.ID)})
+}
+
+func (f *fakeSimpleMDM) updateCustomProfile(w http.ResponseWriter, r *http.Request) {
+	profile := f.profileOfKind(w, r, "custom_configuration_profile")
+	if profile == nil {
+		return
+	}
+	form := fakeForm(r)
+	applyProfileFlags(profile, form)
+	if mobileconfig := form.Get("mobileconfig"); mobileconfig != "" {
+		profile.MobileConfig = mobileconfig
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderProfile(profile.ID)})
+}
+
+func (f *fakeSimpleMDM) downloadCustomProfile(w http.ResponseWriter, r *http.Request) {
+	profile := f.profileOfKind(w, r, "custom_configuration_profile")
+	if profile == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-apple-aspen-config")
+	_, _ = io.WriteString(w, profile.MobileConfig)
+}
+
+func (f *fakeSimpleMDM) createCustomDeclaration(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	if form.Get("name") == "" || form.Get("declaration_type") == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name and declaration_type are required")
+		return
+	}
+	profile := &fakeProfile{ID: f.newID(), Kind: "custom_declaration", UserScope: true, Devices: map[int]bool{}}
+	if !applyDeclarationForm(w, profile, form) {
+		return
+	}
+	f.profiles[profile.ID] = profile
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderProfile(profile.ID)})
+}
+
+func (f *fakeSimpleMDM) updateCustomDeclaration(w http.ResponseWriter, r *http.Request) {
+	profile := f.profileOfKind(w, r, "custom_declaration")
+	if profile == nil {
+		return
+	}
+	if !applyDeclarationForm(w, profile, fakeForm(r)) {
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderProfile(profile.ID)})
+}
+
+func applyDeclarationForm(w http.ResponseWriter, profile *fakeProfile, form url.Values) bool {
+	applyProfileFlags(profile, form)
+	if declarationType := form.Get("declaration_type"); declarationType != "" {
+		profile.DeclarationType = declarationType
+	}
+	if form.Has("activation_predicate") {
+		profile.ActivationPredicate = form.Get("activation_predicate")
+	}
+	for _, key := range []string{"payload", "declaration", "data"} {
+		if payload := form.Get(key); payload != "" {
+			if !json.Valid([]byte(payload)) {
+				writeFakeError(w, http.StatusUnprocessableEntity, "payload is not valid JSON")
+				return false
+			}
+			profile.Payload = payload
+			break
+		}
+	}
+	if profile.Payload == "" {
+		profile.Payload = "{}"
+	}
+	return true
+}
+
+// downloadCustomDeclaration returns the declaration the way devices receive
+// it, SimpleMDM injects its own keys into the payload.
+func (f *fakeSimpleMDM) downloadCustomDeclaration(w http.ResponseWriter, r *http.Request) {
+	profile := f.profileOfKind(w, r, "custom_declaration")
+	if profile == nil {
+		return
+	}
+	payload := map[string]any{}
+	_ = json.Unmarshal([]byte(profile.Payload), &payload)
+	payload["declaration_name"] = profile.Name
+	if profile.ActivationPredicate != "" {
+		payload["activation_predicate"] = profile.ActivationPredicate
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{
+		"Type":        profile.DeclarationType,
+		"Identifier":  fmt.Sprintf("com.simplemdm.declaration.%d", profile.ID),
+		"ServerToken": fmt.Sprintf("%d-%d", profile.ID, len(profile.Payload)),
+		"Payload":     payload,
+	})
+}
+
+func (f *fakeSimpleMDM) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.profiles[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.profiles, id)
+	for _, group := range f.groups {
+		delete(group.Profiles, id)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeSimpleMDM) assignProfileToDevice(w http.ResponseWriter, r *http.Request) {
+	f.changeProfileDevice(w, r, true)
+}
+
+func (f *fakeSimpleMDM) unassignProfileFromDevice(w http.ResponseWriter, r *http.Request) {
+	f.changeProfileDevice(w, r, false)
+}
+
+func (f *fakeSimpleMDM) changeProfileDevice(w http.ResponseWriter, r *http.Request, assign bool) {
+	id, ok := fakePathID(r, "id")
+	deviceID, deviceOK := fakePathID(r, "device")
+	if !ok || !deviceOK || f.profiles[id] == nil || f.devices[deviceID] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	setMembership(f.profiles[id].Devices, deviceID, assign)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---- scripts
+
+func (f *fakeSimpleMDM) renderScript(id int) fakeObject {
+	script := f.scripts[id]
+	return fakeObject{
+		"type": "script",
+		"id":   script.ID,
+		"attributes": fakeObject{
+			"name":             script.Name,
+			"content":          script.Content,
+			"variable_support": script.VariableSupport,
+			"created_at":       script.CreatedAt,
+			"updated_at":       script.UpdatedAt,
+		},
+	}
+}
+
+func (f *fakeSimpleMDM) listScripts(w http.ResponseWriter, r *http.Request) {
+	ids := []int{}
+	for id := range f.scripts {
+		ids = append(ids, id)
+	}
+	writeFakePage(w, r, ids, f.renderScript)
+}
+
+func (f *fakeSimpleMDM) getScript(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.scripts[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderScript(id)})
+}
+
+func (f *fakeSimpleMDM) createScript(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	if form.Get("name") == "" || form.Get("file") == "" {
+		writeFakeError(w, http.StatusUnprocessableEntity, "name and file are required")
+		return
+	}
+	now := fakeTimestamp()
+	script := &fakeScript{
+		ID:              f.newID(),
+		Name:            form.Get("name"),
+		Content:         form.Get("file"),
+		VariableSupport: fakeBool(form, "variable_support", false),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	f.scripts[script.ID] = script
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderScript(script.ID)})
+}
+
+func (f *fakeSimpleMDM) updateScript(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.scripts[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	form := fakeForm(r)
+	script := f.scripts[id]
+	if name := form.Get("name"); name != "" {
+		script.Name = name
+	}
+	if content := form.Get("file"); content != "" {
+		script.Content = content
+	}
+	script.VariableSupport = fakeBool(form, "variable_support", script.VariableSupport)
+	script.UpdatedAt = fakeTimestamp()
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderScript(id)})
+}
+
+func (f *fakeSimpleMDM) deleteScript(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.scripts[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.scripts, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---- script jobs
+
+func (f *fakeSimpleMDM) renderScriptJob(id int) fakeObject {
+	job := f.scriptJobs[id]
+	// Jobs keep the script as it was when they were created.
+	scriptName, content := job.ScriptName, job.Content
+	deviceIDs := []int{}
+	for _, rawID := range job.DeviceIDs {
+		if deviceID, err := strconv.Atoi(rawID); err == nil {
+			deviceIDs = append(deviceIDs, deviceID)
+		}
+	}
+	for _, rawID := range job.AssignmentGroupIDs {
+		if groupID, err := strconv.Atoi(rawID); err == nil && f.groups[groupID] != nil {
+			deviceIDs = append(deviceIDs, keys(f.groups[groupID].Devices)...)
+		}
+	}
+	relationships := fakeObject{"device": relationship("device", deviceIDs)}
+	if job.CustomAttribute != "" {
+		relationships["custom_attribute"] = fakeObject{"data": fakeObject{"type": "custom_attribute", "id": job.CustomAttribute}}
+	}
+	return fakeObject{
+		"type": "script_job",
+		"id":   job.ID,
+		"attributes": fakeObject{
+			"script_name":            scriptName,
+			"job_name":               scriptName,
+			"content":                content,
+			"job_id":                 fmt.Sprintf("%08x", job.ID),
+			"status":                 job.Status,
+			"pending_count":          len(deviceIDs),
+			"success_count":          0,
+			"errored_count":          0,
+			"custom_attribute_regex": job.CustomAttributeRegex,
+		},
+		"relationships": relationships,
+	}
+}
+
+func (f *fakeSimpleMDM) listScriptJobs(w http.ResponseWriter, r *http.Request) {
+	ids := []int{}
+	for id := range f.scriptJobs {
+		ids = append(ids, id)
+	}
+	writeFakePage(w, r, ids, f.renderScriptJob)
+}
+
+func (f *fakeSimpleMDM) getScriptJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.scriptJobs[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, fakeObject{"data": f.renderScriptJob(id)})
+}
+
+func (f *fakeSimpleMDM) createScriptJob(w http.ResponseWriter, r *http.Request) {
+	form := fakeForm(r)
+	scriptID, err := strconv.Atoi(form.Get("script_id"))
+	if err != nil || f.scripts[scriptID] == nil {
+		writeFakeError(w, http.StatusUnprocessableEntity, "script does not exist")
+		return
+	}
+	job := &fakeScriptJob{
+		ID:                   f.newID(),
+		ScriptID:             scriptID,
+		ScriptName:           f.scripts[scriptID].Name,
+		Content:              f.scripts[scriptID].Content,
+		DeviceIDs:            fakeIDList(form, "device_ids"),
+		AssignmentGroupIDs:   fakeIDList(form, "assignment_group_ids", "group_ids"),
+		CustomAttribute:      form.Get("custom_attribute"),
+		CustomAttributeRegex: form.Get("custom_attribute_regex"),
+		Status:               "pending",
+	}
+	if len(job.DeviceIDs) == 0 && len(job.AssignmentGroupIDs) == 0 {
+		writeFakeError(w, http.StatusUnprocessableEntity, "at least one device or group is required")
+		return
+	}
+	f.scriptJobs[job.ID] = job
+	writeFakeJSON(w, http.StatusCreated, fakeObject{"data": f.renderScriptJob(job.ID)})
+}
+
+func (f *fakeSimpleMDM) cancelScriptJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := fakePathID(r, "id")
+	if !ok || f.scriptJobs[id] == nil {
+		writeFakeNotFound(w)
+		return
+	}
+	delete(f.scriptJobs, id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,425 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// httpLogSubsystem is the tflog subsystem for SimpleMDM API traffic. Its
+	// level can be raised independently with TF_LOG_PROVIDER_SIMPLEMDM_HTTP.
+	httpLogSubsystem = "http"
+
+	// maxLoggedBody limits how much of a request or response body is logged.
+	maxLoggedBody = 8 << 10
+
+	redacted = "REDACTED"
+)
+
+// sensitiveKeyPattern matches form and JSON keys whose values are never
+// logged.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(password|passcode|secret|token|apikey|api_key|authorization|cookie|^pin$|_pin$)`)
+
+// newHTTPLogContext prepares the context used to log API traffic. The API
+// key is masked in every message and field, both in the provider root
+// logger and the http subsystem.
+func newHTTPLogContext(ctx context.Context, apikey string) context.Context {
+	ctx = tflog.MaskLogStrings(ctx, apikey)
+	ctx = tflog.NewSubsystem(ctx, httpLogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_SIMPLEMDM", "HTTP"), tflog.WithRootFields())
+	ctx = tflog.SubsystemMaskLogStrings(ctx, httpLogSubsystem, apikey)
+	return ctx
+}
+
+// loggingTransport is a http.RoundTripper which logs every SimpleMDM API
+// call at TRACE level and optionally records it to a HAR file.
+//
+// The SimpleMDM client doesn't pass a context with its requests, so the
+// transport logs with the context the provider was configured with.
+type loggingTransport struct {
+	base     http.RoundTripper
+	logCtx   context.Context
+	apikey   string
+	recorder *harRecorder
+}
+
+func newLoggingTransport(logCtx context.Context, base http.RoundTripper, apikey string, recorder *harRecorder) *loggingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingTransport{
+		base:     base,
+		logCtx:   logCtx,
+		apikey:   apikey,
+		recorder: recorder,
+	}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		tflog.SubsystemTrace(t.logCtx, httpLogSubsystem, "SimpleMDM API call failed", map[string]any{
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"latency_ms": time.Since(started).Milliseconds(),
+			"error":      err.Error(),
+		})
+		return nil, err
+	}
+
+	responseBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	latency := time.Since(started)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	requestType := req.Header.Get("Content-Type")
+	responseType := resp.Header.Get("Content-Type")
+	tflog.SubsystemTrace(t.logCtx, httpLogSubsystem, "SimpleMDM API call", map[string]any{
+		"method":        req.Method,
+		"path":          req.URL.Path,
+		"query":         redactQuery(req.URL.Query()),
+		"status":        resp.StatusCode,
+		"latency_ms":    latency.Milliseconds(),
+		"request_body":  truncateBody(redactBody(requestBody, requestType, t.apikey)),
+		"response_body": truncateBody(redactBody(responseBody, responseType, t.apikey)),
+	})
+
+	if t.recorder != nil {
+		if err := t.recorder.record(req, requestBody, resp, responseBody, started, latency, t.apikey); err != nil {
+			tflog.Warn(t.logCtx, "Could not write SimpleMDM HTTP trace file", map[string]any{"error": err.Error()})
+		}
+	}
+	return resp, nil
+}
+
+// redactBody returns a body safe to log. Values of sensitive keys in form
+// and JSON bodies are replaced and the API key is removed wherever it
+// appears. Multipart bodies carry uploaded files and are only summarized.
+func redactBody(body []byte, contentType, apikey string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	text := string(body)
+	switch mediaType {
+	case "multipart/form-data":
+		return "<multipart form data>"
+	case "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(text); err == nil {
+			text = redactQuery(values)
+		}
+	default:
+		// Bodies without sensitive keys are kept byte for byte.
+		var decoded any
+		if json.Unmarshal(body, &decoded) == nil && redactJSON(decoded) {
+			if encoded, err := json.Marshal(decoded); err == nil {
+				text = string(encoded)
+			}
+		}
+	}
+
+	if apikey != "" {
+		text = strings.ReplaceAll(text, apikey, redacted)
+	}
+	return text
+}
+
+func redactQuery(values url.Values) string {
+	clean := url.Values{}
+	for key, value := range values {
+		if sensitiveKeyPattern.MatchString(key) {
+			clean[key] = []string{redacted}
+			continue
+		}
+		clean[key] = value
+	}
+	return clean.Encode()
+}
+
+// redactJSON replaces the values of sensitive keys in place and reports
+// whether anything was replaced.
+func redactJSON(value any) bool {
+	changed := false
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, item := range typed {
+			if sensitiveKeyPattern.MatchString(key) {
+				typed[key] = redacted
+				changed = true
+				continue
+			}
+			changed = redactJSON(item) || changed
+		}
+	case []any:
+		for _, item := range typed {
+			changed = redactJSON(item) || changed
+		}
+	}
+	return changed
+}
+
+func truncateBody(body string) string {
+	if len(body) <= maxLoggedBody {
+		return body
+	}
+	return body[:maxLoggedBody] + "...(truncated)"
+}
+
+// harRecorder writes SimpleMDM API calls to a file in HTTP Archive (HAR)
+// format. Every provider run starts a new archive. Each call is written over
+// the end of the archive followed by a new end and synced, so the file is
+// valid JSON even if Terraform stops the provider and a write costs only the
+// entry. The file is locked while it is written, provider configurations
+// sharing one in the same run would overwrite each other's entries.
+type harRecorder struct {
+	mu      sync.Mutex
+	path    string
+	version string
+
+	// end is the offset of the closing brackets of the archive, the next
+	// entry is written there.
+	file    *os.File
+	end     int64
+	closing string
+	entries int
+}
+
+type harArchive struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHARRecorder starts an archive without entries in the file at path.
+func newHARRecorder(path, version string) (*harRecorder, error) {
+	r := &harRecorder{path: path, version: version}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *harRecorder) open() error {
+	encoded, err := json.MarshalIndent(harArchive{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "terraform-provider-simplemdm", Version: r.version},
+		Entries: []harEntry{},
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	head, tail, _ := strings.Cut(string(encoded), `"entries": []`)
+	head += `"entries": [`
+	r.closing = "\n    ]" + tail + "\n"
+
+	// Only truncate the file once it is locked, it may be written by
+	// another provider process.
+	file, err := os.OpenFile(r.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("%s is written by another provider configuration, give each one its own http_trace_file: %w", r.path, err)
+	}
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.WriteString(head + "]" + tail + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.end = int64(len(head))
+	return nil
+}
+
+// write adds the entry to the end of the archive.
+func (r *harRecorder) write(entry harEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return os.ErrClosed
+	}
+
+	encoded, err := json.MarshalIndent(entry, "      ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ""
+	if r.entries > 0 {
+		separator = ","
+	}
+	written := separator + "\n      " + string(encoded)
+	if _, err := r.file.WriteAt([]byte(written+r.closing), r.end); err != nil {
+		return err
+	}
+	r.end += int64(len(written))
+	r.entries++
+	return r.file.Sync()
+}
+
+// close closes the file and releases its lock.
+func (r *harRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *harRecorder) record(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, started time.Time, latency time.Duration, apikey string) error {
+	milliseconds := float64(latency.Microseconds()) / 1000
+
+	entry := harEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(resp.Header),
+			Content: harContent{
+				Size:     len(responseBody),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     redactBody(responseBody, resp.Header.Get("Content-Type"), apikey),
+			},
+			HeadersSize: -1,
+			BodySize:    len(responseBody),
+		},
+		Timings: harTimings{Wait: milliseconds},
+	}
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			if sensitiveKeyPattern.MatchString(key) {
+				value = redacted
+			}
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: value})
+		}
+	}
+	if len(requestBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     redactBody(requestBody, req.Header.Get("Content-Type"), apikey),
+		}
+	}
+
+	return r.write(entry)
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveKeyPattern.MatchString(name) {
+				value = redacted
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package provider
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile locks the file for this process until it is closed, it fails
+// right away if another one holds the lock.
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
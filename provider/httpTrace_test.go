@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestLoggingTransportMasksAPIKey(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+
+	var output bytes.Buffer
+	ctx := newHTTPLogContext(tflogtest.RootLogger(context.Background(), &output), fakeAPIKey)
+	tflog.Debug(ctx, "configured with key "+fakeAPIKey, map[string]any{"apikey": fakeAPIKey})
+
+	client := &http.Client{Transport: newLoggingTransport(ctx, fake.transport(), fakeAPIKey, nil)}
+	form := url.Values{"name": {"logged"}, "default_value": {"contains " + fakeAPIKey}, "password": {"hunter2"}}
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodPost, "/api/v1/custom_attributes", form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "logged") {
+		t.Fatalf("expected the response body to still be readable, got %q", body)
+	}
+
+	for _, secret := range []string{fakeAPIKey, "hunter2"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("expected %q to be masked in the logs:\n%s", secret, output.String())
+		}
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace map[string]any
+	for _, entry := range entries {
+		if entry["@message"] == "SimpleMDM API call" {
+			trace = entry
+		}
+	}
+	if trace == nil {
+		t.Fatalf("expected a trace entry for the API call, got %v", entries)
+	}
+	if trace["@level"] != "trace" || trace["@module"] != "provider.http" {
+		t.Errorf("expected trace level in the http subsystem, got %v %v", trace["@level"], trace["@module"])
+	}
+	for key, expect := range map[string]any{"method": "POST", "path": "/api/v1/custom_attributes", "status": float64(201)} {
+		if trace[key] != expect {
+			t.Errorf("expected %s %v, got %v", key, expect, trace[key])
+		}
+	}
+	if _, ok := trace["latency_ms"]; !ok {
+		t.Error("expected latency_ms to be logged")
+	}
+	if !strings.Contains(trace["request_body"].(string), "password=REDACTED") {
+		t.Errorf("expected the password to be redacted in the request body, got %v", trace["request_body"])
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := map[string]struct {
+		body        string
+		contentType string
+		expect      string
+	}{
+		"json": {
+			body:        `{"data":{"attributes":{"name":"device","unlock_pin":"123456","bootstrap_token":"abc"}}}`,
+			contentType: "application/json; charset=utf-8",
+			expect:      `{"data":{"attributes":{"bootstrap_token":"REDACTED","name":"device","unlock_pin":"REDACTED"}}}`,
+		},
+		"form": {
+			body:        "name=device&pin=123456",
+			contentType: "application/x-www-form-urlencoded",
+			expect:      "name=device&pin=REDACTED",
+		},
+		"multipart": {
+			body:        "--boundary\r\n...",
+			contentType: "multipart/form-data; boundary=boundary",
+			expect:      "<multipart form data>",
+		},
+		"api key in plain text": {
+			body:        "invalid key " + fakeAPIKey,
+			contentType: "text/plain",
+			expect:      "invalid key REDACTED",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := redactBody([]byte(test.body), test.contentType, fakeAPIKey); got != test.expect {
+				t.Errorf("expected %q, got %q", test.expect, got)
+			}
+		})
+	}
+}
+
+// TestHTTPTraceFileReplay records API calls made against the stand-in
+// server and replays the trace file from a second server.
+func TestHTTPTraceFileReplay(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+
+	tracePath := filepath.Join(t.TempDir(), "trace.har")
+	recorder, err := newHARRecorder(tracePath, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: newLoggingTransport(context.Background(), fake.transport(), fakeAPIKey, recorder)}
+
+	calls := []struct {
+		method string
+		path   string
+		form   url.Values
+	}{
+		{http.MethodGet, "/api/v1/apps/577575", nil},
+		{http.MethodPost, "/api/v1/custom_attributes", url.Values{"name": {"traced"}}},
+		{http.MethodGet, "/api/v1/custom_attributes/traced", nil},
+		{http.MethodGet, "/api/v1/devices/1", nil},
+	}
+	recorded := []string{}
+	for _, call := range calls {
+		resp, err := client.Do(fakeRequest(t, fake, call.method, call.path, call.form))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		recorded = append(recorded, resp.Status+" "+string(body))
+	}
+
+	content, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), fakeAPIKey) {
+		t.Error("expected the API key to be redacted in the trace file")
+	}
+
+	var archive harArchive
+	if err := json.Unmarshal(content, &archive); err != nil {
+		t.Fatalf("trace file is not valid JSON: %s", err)
+	}
+	if len(archive.Log.Entries) != len(calls) {
+		t.Fatalf("expected %d entries, got %d", len(calls), len(archive.Log.Entries))
+	}
+	for _, header := range archive.Log.Entries[0].Request.Headers {
+		if header.Name == "Authorization" && header.Value != redacted {
+			t.Errorf("expected the Authorization header to be redacted, got %q", header.Value)
+		}
+	}
+
+	// Serve the recorded responses in order for matching method and path.
+	entries := archive.Log.Entries
+	replay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i, entry := range entries {
+			recordedURL, _ := url.Parse(entry.Request.URL)
+			if entry.Request.Method == r.Method && recordedURL.Path == r.URL.Path {
+				entries = append(entries[:i:i], entries[i+1:]...)
+				w.Header().Set("Content-Type", entry.Response.Content.MimeType)
+				w.WriteHeader(entry.Response.Status)
+				_, _ = io.WriteString(w, entry.Response.Content.Text)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer replay.Close()
+
+	for i, call := range calls {
+		req, _ := http.NewRequest(call.method, replay.URL+call.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got := resp.Status + " " + string(body); got != recorded[i] {
+			t.Errorf("call %d: expected replay %q, got %q", i, recorded[i], got)
+		}
+	}
+
+	// Another provider configuration can't write to the file at the same
+	// time.
+	if _, err := newHARRecorder(tracePath, "test"); err == nil || !strings.Contains(err.Error(), "give each one its own http_trace_file") {
+		t.Errorf("expected the file in use to be refused, got %v", err)
+	}
+
+	// A second recorder, as created by the next provider run, starts a new
+	// archive.
+	if err := recorder.close(); err != nil {
+		t.Fatal(err)
+	}
+	recorder, err = newHARRecorder(tracePath, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.close()
+	client = &http.Client{Transport: newLoggingTransport(context.Background(), fake.transport(), fakeAPIKey, recorder)}
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodGet, "/api/v1/apps/577575", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	content, err = os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive = harArchive{}
+	if err := json.Unmarshal(content, &archive); err != nil {
+		t.Fatalf("trace file is not valid JSON: %s", err)
+	}
+	if len(archive.Log.Entries) != 1 || archive.Log.Creator.Name != "terraform-provider-simplemdm" {
+		t.Errorf("expected a new archive with one entry, got %d entries", len(archive.Log.Entries))
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// importLookup returns the IDs of the objects matching the value of a
+// prefixed import ID such as name:Engineering Macs.
+type importLookup func(ctx context.Context, value string) ([]int, error)
+
+// importByLookup imports the object req.ID refers to. IDs without a known
+// prefix are used as they are, for prefix:value the lookup of the prefix has
+// to find exactly one object.
+func importByLookup(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse, object string, lookups map[string]importLookup) {
+	id := req.ID
+	prefix, value, found := strings.Cut(req.ID, ":")
+	if lookup := lookups[prefix]; found && lookup != nil {
+		if value == "" {
+			resp.Diagnostics.AddError(
+				"Invalid Import ID",
+				fmt.Sprintf("Import ID %q is missing the %s after %s:.", req.ID, prefix, prefix),
+			)
+			return
+		}
+
+		ids, err := lookup(ctx, value)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostic(
+				"Error importing "+object,
+				fmt.Sprintf("Could not look up the %s with %s %q", object, prefix, value),
+				err,
+			))
+			return
+		}
+		sort.Ints(ids)
+
+		switch len(ids) {
+		case 0:
+			resp.Diagnostics.AddError(
+				"Import Object Not Found",
+				fmt.Sprintf("No %s in SimpleMDM has the %s %q.", object, prefix, value),
+			)
+			return
+		case 1:
+			id = strconv.Itoa(ids[0])
+		default:
+			matches := make([]string, len(ids))
+			for i, match := range ids {
+				matches[i] = strconv.Itoa(match)
+			}
+			resp.Diagnostics.AddError(
+				"Ambiguous Import ID",
+				fmt.Sprintf("%d %ss in SimpleMDM have the %s %q, their IDs are %s. Import one of them by its ID instead.", len(ids), object, prefix, value, strings.Join(matches, ", ")),
+			)
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+// assignmentGroupsNamed looks up assignment groups by their name.
+func assignmentGroupsNamed(client *simplemdmClient) importLookup {
+	return func(ctx context.Context, name string) ([]int, error) {
+		groups, err := listAll[apiAssignmentGroup](ctx, client, "assignment_groups", nil)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, group := range groups {
+			if group.Attributes.Name == name {
+				ids = append(ids, group.ID)
+			}
+		}
+		return ids, nil
+	}
+}
+
+// appsWithBundleID looks up apps by their bundle identifier.
+func appsWithBundleID(client *simplemdmClient) importLookup {
+	return func(ctx context.Context, bundleID string) ([]int, error) {
+		apps, err := listAll[apiApp](ctx, client, "apps", nil)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, app := range apps {
+			if app.Attributes.BundleID == bundleID {
+				ids = append(ids, app.ID)
+			}
+		}
+		return ids, nil
+	}
+}
+
+// customProfilesNamed looks up custom configuration profiles by their name.
+func customProfilesNamed(client *simplemdmClient) importLookup {
+	return func(ctx context.Context, name string) ([]int, error) {
+		profiles, err := listAll[apiProfile](ctx, client, "custom_configuration_profiles", nil)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, profile := range profiles {
+			if profile.Attributes.Name == name {
+				ids = append(ids, profile.ID)
+			}
+		}
+		return ids, nil
+	}
+}
+
+// devicesWithSerial looks up devices by their serial number, serial numbers
+// are compared without case.
+func devicesWithSerial(client *simplemdmClient) importLookup {
+	return func(ctx context.Context, serial string) ([]int, error) {
+		devices, err := listAll[apiDevice](ctx, client, "devices", map[string][]string{"search": {serial}})
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, device := range devices {
+			if strings.EqualFold(device.Attributes.SerialNumber, serial) {
+				ids = append(ids, device.ID)
+			}
+		}
+		return ids, nil
+	}
+}
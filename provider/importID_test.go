@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testImport calls ImportState of r with importID and returns the imported
+// id, or the error details.
+func testImport(t *testing.T, r resource.ResourceWithImportState, importID string) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	resp := &resource.ImportStateResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: importID}, resp)
+	if resp.Diagnostics.HasError() {
+		var details []string
+		for _, diagnostic := range resp.Diagnostics.Errors() {
+			details = append(details, diagnostic.Summary()+": "+diagnostic.Detail())
+		}
+		return "", strings.Join(details, "\n")
+	}
+
+	var id string
+	if diags := resp.State.GetAttribute(ctx, path.Root("id"), &id); diags.HasError() {
+		t.Fatal(diags)
+	}
+	return id, ""
+}
+
+func TestImportByName(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	// More groups than fit on one page of the list endpoint.
+	for i := range 150 {
+		id := 3000000 + i
+		fake.groups[id] = newFakeGroup(id, fmt.Sprintf("Paged Group %d", i))
+	}
+	fake.groups[3999999] = newFakeGroup(3999999, "Test Group")
+
+	groups := &assignment_groupResource{client: fake.client()}
+	devices := &deviceResource{client: fake.client()}
+	profiles := &customProfileResource{client: fake.client()}
+
+	tests := map[string]struct {
+		resource resource.ResourceWithImportState
+		importID string
+		expectID string
+		errorMsg string
+	}{
+		"group by ID": {
+			resource: groups,
+			importID: "140189",
+			expectID: "140189",
+		},
+		"group by name": {
+			resource: groups,
+			importID: "name:Test Group 2",
+			expectID: "140189",
+		},
+		"group on a later page": {
+			resource: groups,
+			importID: "name:Paged Group 142",
+			expectID: "3000142",
+		},
+		"group name with a colon": {
+			resource: groups,
+			importID: "name:Engineering: Macs",
+			errorMsg: `Import Object Not Found: No assignment group in SimpleMDM has the name "Engineering: Macs".`,
+		},
+		"ambiguous group name": {
+			resource: groups,
+			importID: "name:Test Group",
+			errorMsg: `Ambiguous Import ID: 2 assignment groups in SimpleMDM have the name "Test Group", their IDs are 140188, 3999999. Import one of them by its ID instead.`,
+		},
+		"missing name": {
+			resource: groups,
+			importID: "name:",
+			errorMsg: `Invalid Import ID: Import ID "name:" is missing the name after name:.`,
+		},
+		"device by serial": {
+			resource: devices,
+			importID: "serial:c02fake00002",
+			expectID: "1601810",
+		},
+		"device by partial serial": {
+			resource: devices,
+			importID: "serial:C02FAKE",
+			errorMsg: `Import Object Not Found: No device in SimpleMDM has the serial "C02FAKE".`,
+		},
+		"custom profile by name": {
+			resource: profiles,
+			importID: "name:Custom Profile 2",
+			expectID: "172805",
+		},
+		"profiles which aren't custom": {
+			resource: profiles,
+			importID: "name:Log in screen",
+			errorMsg: `Import Object Not Found: No custom profile in SimpleMDM has the name "Log in screen".`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			id, errorMsg := testImport(t, test.resource, test.importID)
+			if errorMsg != test.errorMsg {
+				t.Fatalf("expected error %q, got %q", test.errorMsg, errorMsg)
+			}
+			if id != test.expectID {
+				t.Errorf("expected ID %q, got %q", test.expectID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// TestImportGeneratedConfigRoundTrip imports every resource from the
+// stand-in server and plans the configuration terraform plan
+// -generate-config-out writes for it, which has to show no changes.
+func TestImportGeneratedConfigRoundTrip(t *testing.T) {
+	if testAccFake == nil {
+		t.Skip("imports the fixtures of the stand-in server")
+	}
+	ctx := context.Background()
+
+	job, err := testAccFake.client().ScriptJobCreate("5727", []string{"1601809", "1601810"}, nil, "testAttribute", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := testAccFake.client().ScriptCancelJob(strconv.Itoa(job.Data.ID)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	server, err := providerserver.NewProtocol6WithError(&simplemdmProvider{version: "test", transport: testAccTransport})()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, schemas.Diagnostics)
+
+	// SIMPLEMDM_HOST and SIMPLEMDM_APIKEY point the provider at the
+	// stand-in server.
+	providerConfig := testDynamicValue(t, nullObject(schemas.Provider.ValueType()))
+	configured, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: providerConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, configured.Diagnostics)
+
+	importIDs := map[string]string{
+		"simplemdm_app":               "577575",
+		"simplemdm_assignmentgroup":   "140188",
+		"simplemdm_attribute":         "testAttribute",
+		"simplemdm_customdeclaration": "214709",
+		"simplemdm_customprofile":     "name:Custom Profile 1",
+		"simplemdm_device":            "serial:C02FAKE00001",
+		"simplemdm_script":            "5727",
+		"simplemdm_scriptjob":         strconv.Itoa(job.Data.ID),
+	}
+	if len(importIDs) != len(schemas.ResourceSchemas) {
+		t.Fatalf("expected an import ID for each of the %d resources, got %d", len(schemas.ResourceSchemas), len(importIDs))
+	}
+
+	for typeName, importID := range importIDs {
+		t.Run(typeName, func(t *testing.T) {
+			schema := schemas.ResourceSchemas[typeName]
+			if schema == nil {
+				t.Fatalf("provider has no resource %s", typeName)
+			}
+			objectType := schema.ValueType().(tftypes.Object)
+
+			imported, err := server.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{TypeName: typeName, ID: importID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testNoDiagnostics(t, imported.Diagnostics)
+			if len(imported.ImportedResources) != 1 {
+				t.Fatalf("expected one imported resource, got %d", len(imported.ImportedResources))
+			}
+
+			read, err := server.ReadResource(ctx, &tfprotov6.ReadResourceRequest{
+				TypeName:     typeName,
+				CurrentState: imported.ImportedResources[0].State,
+				Private:      imported.ImportedResources[0].Private,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testNoDiagnostics(t, read.Diagnostics)
+			state, err := read.NewState.Unmarshal(objectType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.IsNull() {
+				t.Fatal("read removed the imported resource")
+			}
+
+			// The generated configuration has every argument of the state,
+			// Terraform leaves out the attributes which are only computed.
+			var stateAttributes map[string]tftypes.Value
+			if err := state.As(&stateAttributes); err != nil {
+				t.Fatal(err)
+			}
+			configAttributes := map[string]tftypes.Value{}
+			proposedAttributes := map[string]tftypes.Value{}
+			for _, attribute := range schema.Block.Attributes {
+				value := stateAttributes[attribute.Name]
+				if attribute.Computed && !attribute.Optional {
+					value = tftypes.NewValue(objectType.AttributeTypes[attribute.Name], nil)
+				}
+				configAttributes[attribute.Name] = value
+				proposedAttributes[attribute.Name] = value
+				if value.IsNull() && attribute.Computed {
+					proposedAttributes[attribute.Name] = stateAttributes[attribute.Name]
+				}
+			}
+			config := testDynamicValue(t, tftypes.NewValue(objectType, configAttributes))
+
+			validated, err := server.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{TypeName: typeName, Config: config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testNoDiagnostics(t, validated.Diagnostics)
+
+			planned, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+				TypeName:         typeName,
+				PriorState:       read.NewState,
+				ProposedNewState: testDynamicValue(t, tftypes.NewValue(objectType, proposedAttributes)),
+				Config:           config,
+				PriorPrivate:     read.Private,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testNoDiagnostics(t, planned.Diagnostics)
+			if len(planned.RequiresReplace) > 0 {
+				t.Errorf("plan replaces the resource because of %v", planned.RequiresReplace)
+			}
+			plannedState, err := planned.PlannedState.Unmarshal(objectType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diffs, err := state.Diff(plannedState)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, diff := range diffs {
+				t.Errorf("plan changes %s from %v to %v", diff.Path, diff.Value1, diff.Value2)
+			}
+		})
+	}
+}
+
+func testDynamicValue(t *testing.T, value tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+	dynamicValue, err := tfprotov6.NewDynamicValue(value.Type(), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dynamicValue
+}
+
+// testNoDiagnostics fails the test for error diagnostics, warnings are
+// logged.
+func testNoDiagnostics(t *testing.T, diagnostics []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+		t.Logf("%s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+}
+
+// TestImportScriptJobWithoutScript imports a job whose script was edited
+// since it ran, the generated configuration leaves script_id to the user.
+func TestImportScriptJobWithoutScript(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	ctx := context.Background()
+	job, err := fake.client().ScriptJobCreate("5727", []string{"1601809"}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobID := strconv.Itoa(job.Data.ID)
+	fake.scripts[5727].Content = "#!/bin/bash\necho \"Edited\""
+
+	server, schemas := testActionServer(t, fake)
+	objectType := schemas.ResourceSchemas["simplemdm_scriptjob"].ValueType().(tftypes.Object)
+	importJob := func(importID string) (*tfprotov6.ReadResourceResponse, map[string]tftypes.Value) {
+		t.Helper()
+		imported, err := server.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{TypeName: "simplemdm_scriptjob", ID: importID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testNoDiagnostics(t, imported.Diagnostics)
+		read, err := server.ReadResource(ctx, &tfprotov6.ReadResourceRequest{TypeName: "simplemdm_scriptjob", CurrentState: imported.ImportedResources[0].State})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testNoDiagnostics(t, read.Diagnostics)
+		state, err := read.NewState.Unmarshal(objectType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attributes map[string]tftypes.Value
+		if err := state.As(&attributes); err != nil {
+			t.Fatal(err)
+		}
+		return read, attributes
+	}
+
+	withScript := tftypes.NewValue(tftypes.String, "5727")
+	if _, attributes := importJob(jobID + ":5727"); !attributes["script_id"].Equal(withScript) || attributes["device_ids"].IsNull() {
+		t.Errorf("expected the job imported with its script to have script_id and device_ids, got %v", attributes)
+	}
+
+	read, attributes := importJob(jobID)
+	if len(read.Diagnostics) != 1 || read.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityWarning {
+		t.Errorf("expected a warning about the script, got %v", read.Diagnostics)
+	}
+	if !attributes["script_id"].IsNull() {
+		t.Fatalf("expected no script_id, got %v", attributes["script_id"])
+	}
+
+	// The user fills in script_id, which is saved without replacing the job.
+	configAttributes := map[string]tftypes.Value{}
+	for name, value := range attributes {
+		configAttributes[name] = value
+	}
+	configAttributes["id"] = tftypes.NewValue(tftypes.String, nil)
+	configAttributes["script_id"] = withScript
+	config := testDynamicValue(t, tftypes.NewValue(objectType, configAttributes))
+	proposedAttributes := map[string]tftypes.Value{}
+	for name, value := range configAttributes {
+		proposedAttributes[name] = value
+	}
+	proposedAttributes["id"] = attributes["id"]
+	planned, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "simplemdm_scriptjob",
+		PriorState:       read.NewState,
+		ProposedNewState: testDynamicValue(t, tftypes.NewValue(objectType, proposedAttributes)),
+		Config:           config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, planned.Diagnostics)
+	if len(planned.RequiresReplace) > 0 {
+		t.Errorf("plan replaces the job because of %v", planned.RequiresReplace)
+	}
+	applied, err := server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     "simplemdm_scriptjob",
+		PriorState:   read.NewState,
+		PlannedState: planned.PlannedState,
+		Config:       config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoDiagnostics(t, applied.Diagnostics)
+	state, err := applied.NewState.Unmarshal(objectType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var appliedAttributes map[string]tftypes.Value
+	if err := state.As(&appliedAttributes); err != nil {
+		t.Fatal(err)
+	}
+	if !appliedAttributes["script_id"].Equal(withScript) {
+		t.Errorf("expected script_id 5727 after applying, got %v", appliedAttributes["script_id"])
+	}
+	if len(fake.scriptJobs) != 1 {
+		t.Errorf("expected the job to be kept, got %d jobs", len(fake.scriptJobs))
+	}
+}
@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+// cachedListPattern matches the list endpoints whose responses are cached.
+// Single objects are always fetched fresh.
+var cachedListPattern = regexp.MustCompile(`/api/v1/(profiles|custom_configuration_profiles|apps|devices|assignment_groups|custom_attributes)/?$`)
+
+// listCache is a http.RoundTripper which caches successful responses of
+// SimpleMDM list endpoints for the lifetime of a provider instance, so
+// resources refreshing in the same run share one download of, for example,
+// the profile list.
+//
+// Entries expire after the TTL and every write the provider sends (any
+// method but GET and HEAD) clears the whole cache, since a write to one
+// object also changes the relationships listed for others. Concurrent
+// requests for the same page wait for the first one instead of all going
+// to the API.
+type listCache struct {
+	base   http.RoundTripper
+	ttl    time.Duration
+	logCtx context.Context
+	now    func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]*listCacheEntry
+	generation uint64
+	stats      listCacheStats
+}
+
+type listCacheEntry struct {
+	// ready is closed once the response is stored or the fetch failed.
+	ready   chan struct{}
+	expires time.Time
+	status  int
+	header  http.Header
+	body    []byte
+	ok      bool
+}
+
+type listCacheStats struct {
+	Hits          int
+	Misses        int
+	Invalidations int
+}
+
+func newListCache(logCtx context.Context, base http.RoundTripper, ttl time.Duration) *listCache {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &listCache{
+		base:    base,
+		ttl:     ttl,
+		logCtx:  logCtx,
+		now:     time.Now,
+		entries: map[string]*listCacheEntry{},
+	}
+}
+
+func (c *listCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		c.Invalidate()
+		resp, err := c.base.RoundTrip(req)
+		// Invalidate again, lists fetched while the write was in flight may
+		// already be stale.
+		c.Invalidate()
+		return resp, err
+	}
+
+	if c.ttl <= 0 || req.Method != http.MethodGet || !cachedListPattern.MatchString(req.URL.Path) {
+		return c.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	for {
+		c.mu.Lock()
+		entry, found := c.entries[key]
+		if found {
+			select {
+			case <-entry.ready:
+				if entry.ok && c.now().Before(entry.expires) {
+					c.stats.Hits++
+					stats := c.stats
+					c.mu.Unlock()
+					c.logStats("SimpleMDM list cache hit", req, stats)
+					return entry.response(req), nil
+				}
+				// Expired or failed, fetch it again.
+				delete(c.entries, key)
+				c.mu.Unlock()
+				continue
+			default:
+				c.mu.Unlock()
+				// Another request is fetching the same page.
+				select {
+				case <-entry.ready:
+					continue
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+		}
+
+		entry = &listCacheEntry{ready: make(chan struct{})}
+		c.entries[key] = entry
+		c.stats.Misses++
+		generation := c.generation
+		stats := c.stats
+		c.mu.Unlock()
+		c.logStats("SimpleMDM list cache miss", req, stats)
+
+		return c.fetch(req, key, entry, generation)
+	}
+}
+
+func (c *listCache) fetch(req *http.Request, key string, entry *listCacheEntry, generation uint64) (*http.Response, error) {
+	defer close(entry.ready)
+
+	resp, err := c.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		c.drop(key, entry)
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		c.drop(key, entry)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry.status = resp.StatusCode
+	entry.header = resp.Header.Clone()
+	entry.body = body
+	entry.expires = c.now().Add(c.ttl)
+	// Don't keep a response which may predate a write.
+	entry.ok = generation == c.generation
+	if !entry.ok && c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (c *listCache) drop(key string, entry *listCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+}
+
+// Invalidate removes every cached response.
+func (c *listCache) Invalidate() {
+	c.mu.Lock()
+	c.generation++
+	if len(c.entries) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	c.entries = map[string]*listCacheEntry{}
+	c.stats.Invalidations++
+	stats := c.stats
+	c.mu.Unlock()
+
+	tflog.Debug(c.logCtx, "SimpleMDM list cache invalidated", stats.fields())
+}
+
+// Generation returns a number which changes whenever the cache is
+// invalidated, for caching what is derived from list responses.
+func (c *listCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Stats returns the counters of the cache.
+func (c *listCache) Stats() listCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *listCache) logStats(message string, req *http.Request, stats listCacheStats) {
+	fields := stats.fields()
+	fields["path"] = req.URL.Path
+	fields["query"] = req.URL.RawQuery
+	tflog.Debug(c.logCtx, message, fields)
+}
+
+func (s listCacheStats) fields() map[string]any {
+	return map[string]any{
+		"cache_hits":          s.Hits,
+		"cache_misses":        s.Misses,
+		"cache_invalidations": s.Invalidations,
+	}
+}
+
+func (e *listCacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
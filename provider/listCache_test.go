@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func cachedGet(t *testing.T, client *http.Client, fake *fakeSimpleMDM, path string) string {
+	t.Helper()
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodGet, path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestListCacheSharesListResponses(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	cache := newListCache(context.Background(), fake.transport(), time.Minute)
+	client := &http.Client{Transport: cache}
+
+	first := cachedGet(t, client, fake, "/api/v1/profiles?limit=100")
+	second := cachedGet(t, client, fake, "/api/v1/profiles?limit=100")
+	if first != second {
+		t.Errorf("expected the cached response to match, got %q and %q", first, second)
+	}
+	// A different page is a different entry.
+	cachedGet(t, client, fake, "/api/v1/profiles?limit=1")
+	// Single objects are never cached.
+	cachedGet(t, client, fake, "/api/v1/profiles/172801")
+	cachedGet(t, client, fake, "/api/v1/profiles/172801")
+
+	if fake.requests != 4 {
+		t.Errorf("expected 4 requests to reach the server, got %d", fake.requests)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("expected 1 hit and 2 misses, got %+v", stats)
+	}
+}
+
+func TestListCacheInvalidatedByWrites(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	cache := newListCache(context.Background(), fake.transport(), time.Minute)
+	client := &http.Client{Transport: cache}
+
+	before := cachedGet(t, client, fake, "/api/v1/profiles?limit=100")
+	if strings.Contains(before, `"device_groups":{"data":[{"id":140188`) {
+		t.Fatal("profile is already assigned to the group")
+	}
+
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodPost, "/api/v1/assignment_groups/140188/profiles/172801", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	after := cachedGet(t, client, fake, "/api/v1/profiles?limit=100")
+	if !strings.Contains(after, `"device_groups":{"data":[{"id":140188`) {
+		t.Errorf("expected the profile list to be fetched again after the write, got %s", after)
+	}
+	if stats := cache.Stats(); stats.Invalidations != 1 || stats.Hits != 0 {
+		t.Errorf("expected 1 invalidation and no hits, got %+v", stats)
+	}
+}
+
+func TestListCacheExpires(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	cache := newListCache(context.Background(), fake.transport(), time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	client := &http.Client{Transport: cache}
+
+	cachedGet(t, client, fake, "/api/v1/apps")
+	now = now.Add(30 * time.Second)
+	cachedGet(t, client, fake, "/api/v1/apps")
+	now = now.Add(time.Minute)
+	cachedGet(t, client, fake, "/api/v1/apps")
+
+	if fake.requests != 2 {
+		t.Errorf("expected the expired entry to be fetched again, got %d requests", fake.requests)
+	}
+}
+
+func TestListCacheDeduplicatesConcurrentRequests(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	cache := newListCache(context.Background(), fake.transport(), time.Minute)
+	client := &http.Client{Transport: cache}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, fake.server.URL+"/api/v1/assignment_groups", nil)
+			req.SetBasicAuth(fakeAPIKey, "")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if fake.requests != 1 {
+		t.Errorf("expected a single request to reach the server, got %d", fake.requests)
+	}
+}
+
+func TestListCacheDisabled(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	client := &http.Client{Transport: newListCache(context.Background(), fake.transport(), 0)}
+
+	cachedGet(t, client, fake, "/api/v1/devices")
+	cachedGet(t, client, fake, "/api/v1/devices")
+
+	if fake.requests != 2 {
+		t.Errorf("expected caching to be disabled, got %d requests", fake.requests)
+	}
+}
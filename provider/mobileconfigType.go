@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ basetypes.StringTypable                    = mobileconfigType{}
+	_ basetypes.StringValuableWithSemanticEquals = mobileconfigValue{}
+)
+
+// mobileconfigType is the type of attributes holding a configuration
+// profile. Its values are equal when both profiles decode to the same
+// property list, so SimpleMDM returning the profile with other whitespace,
+// key order or serialization doesn't show up as a change.
+type mobileconfigType struct {
+	basetypes.StringType
+}
+
+func (t mobileconfigType) String() string {
+	return "mobileconfigType"
+}
+
+func (t mobileconfigType) ValueType(_ context.Context) attr.Value {
+	return mobileconfigValue{}
+}
+
+func (t mobileconfigType) Equal(o attr.Type) bool {
+	other, ok := o.(mobileconfigType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t mobileconfigType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return mobileconfigValue{StringValue: in}, nil
+}
+
+func (t mobileconfigType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return mobileconfigValue{StringValue: stringValue}, nil
+}
+
+// mobileconfigValue is a value of mobileconfigType.
+type mobileconfigValue struct {
+	basetypes.StringValue
+}
+
+// newMobileconfigValue returns a known mobileconfig value.
+func newMobileconfigValue(mobileconfig string) mobileconfigValue {
+	return mobileconfigValue{StringValue: basetypes.NewStringValue(mobileconfig)}
+}
+
+func (v mobileconfigValue) Type(_ context.Context) attr.Type {
+	return mobileconfigType{}
+}
+
+func (v mobileconfigValue) Equal(o attr.Value) bool {
+	other, ok := o.(mobileconfigValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+// StringSemanticEquals reports whether both profiles decode to the same
+// property list. Profiles which don't decode are only equal when their text
+// is.
+func (v mobileconfigValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(mobileconfigValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T but got value type %T. Please report this to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	if v.ValueString() == newValue.ValueString() {
+		return true, diags
+	}
+	prior, err := decodePlist([]byte(strings.TrimSpace(v.ValueString())))
+	if err != nil {
+		return false, diags
+	}
+	current, err := decodePlist([]byte(strings.TrimSpace(newValue.ValueString())))
+	if err != nil {
+		return false, diags
+	}
+	return plistValuesEqual(prior, current), diags
+}
+
+// plistValuesEqual compares two decoded property lists, an integer and a
+// real are different values even when they hold the same number.
+func plistValuesEqual(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, found := b[key]
+			if !found || !plistValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !plistValuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+
+	case []byte:
+		b, ok := b.([]byte)
+		return ok && bytes.Equal(a, b)
+
+	case time.Time:
+		b, ok := b.(time.Time)
+		return ok && a.Equal(b)
+	}
+	return a == b
+}
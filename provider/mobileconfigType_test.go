@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestMobileconfigValueSemanticEquals(t *testing.T) {
+	const profile = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+    <key>PayloadIdentifier</key>
+    <string>com.example.profile</string>
+    <key>PayloadVersion</key>
+    <integer>1</integer>
+    <key>PayloadContent</key>
+    <array>
+        <dict>
+            <key>Enabled</key>
+            <true/>
+            <key>Blob</key>
+            <data>
+                aGVs
+                bG8=
+            </data>
+        </dict>
+    </array>
+</dict>
+</plist>`
+
+	tests := map[string]struct {
+		other string
+		equal bool
+	}{
+		"same text": {
+			other: profile,
+			equal: true,
+		},
+		"whitespace and key order": {
+			other: `<plist version="1.0"><dict><key>PayloadContent</key><array><dict><key>Blob</key><data>aGVsbG8=</data><key>Enabled</key><true/></dict></array>` +
+				`<key>PayloadVersion</key><integer>1</integer><key>PayloadIdentifier</key><string>com.example.profile</string></dict></plist>`,
+			equal: true,
+		},
+		"changed value": {
+			other: strings.Replace(profile, "<true/>", "<false/>", 1),
+			equal: false,
+		},
+		"integer turned real": {
+			other: strings.Replace(profile, "<integer>1</integer>", "<real>1</real>", 1),
+			equal: false,
+		},
+		"whitespace inside a string": {
+			other: strings.Replace(profile, "<string>com.example.profile</string>", "<string> com.example.profile</string>", 1),
+			equal: false,
+		},
+		"not a plist": {
+			other: "PayloadIdentifier = com.example.profile",
+			equal: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			equal, diags := newMobileconfigValue(profile).StringSemanticEquals(context.Background(), newMobileconfigValue(test.other))
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if equal != test.equal {
+				t.Errorf("expected semantic equality %t, got %t", test.equal, equal)
+			}
+		})
+	}
+
+	_, diags := newMobileconfigValue(profile).StringSemanticEquals(context.Background(), types.StringValue(profile))
+	if !diags.HasError() {
+		t.Error("expected an error comparing with a plain string value")
+	}
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// payloadTypePattern matches reverse DNS payload types such as
+// com.apple.wifi.managed.
+var payloadTypePattern = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9_-]+)+$`)
+
+// mobileconfigValidator checks at plan time that a string attribute holds a
+// configuration profile SimpleMDM will accept, instead of failing the upload
+// during apply.
+type mobileconfigValidator struct{}
+
+func (v mobileconfigValidator) Description(_ context.Context) string {
+	return "value must be a configuration profile with PayloadType Configuration, PayloadIdentifier, PayloadUUID and PayloadContent"
+}
+
+func (v mobileconfigValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v mobileconfigValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for _, problem := range mobileconfigProblems(req.ConfigValue.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Configuration Profile",
+			fmt.Sprintf("Attribute %s is not a valid configuration profile: %s.", req.Path, problem),
+		)
+	}
+}
+
+// mobileconfigProblems returns everything wrong with a profile, empty when
+// it is fine.
+func mobileconfigProblems(mobileconfig string) []string {
+	value, err := decodePlist([]byte(strings.TrimSpace(mobileconfig)))
+	if err != nil {
+		return []string{"the property list can't be parsed, " + err.Error()}
+	}
+	profile, ok := value.(map[string]any)
+	if !ok {
+		return []string{"the property list doesn't hold a dictionary"}
+	}
+
+	var problems []string
+	if payloadType, _ := profile["PayloadType"].(string); payloadType != "Configuration" {
+		problems = append(problems, fmt.Sprintf("the top level PayloadType must be Configuration, got %s", describePlistValue(profile["PayloadType"])))
+	}
+	for _, key := range []string{"PayloadIdentifier", "PayloadUUID"} {
+		if text, _ := profile[key].(string); text == "" {
+			problems = append(problems, "the top level "+key+" must be set to a string")
+		}
+	}
+
+	content, found := profile["PayloadContent"]
+	if !found {
+		return append(problems, "the top level PayloadContent is missing")
+	}
+	payloads, ok := content.([]any)
+	if !ok {
+		return append(problems, "the top level PayloadContent must be an array of payload dictionaries")
+	}
+
+	// Where each PayloadUUID was first seen, UUIDs are compared without case.
+	uuids := map[string]string{}
+	if uuid, ok := profile["PayloadUUID"].(string); ok && uuid != "" {
+		uuids[strings.ToUpper(uuid)] = "the profile"
+	}
+
+	for i, element := range payloads {
+		location := fmt.Sprintf("PayloadContent[%d]", i)
+		payload, ok := element.(map[string]any)
+		if !ok {
+			problems = append(problems, location+" must be a dictionary")
+			continue
+		}
+
+		payloadType, _ := payload["PayloadType"].(string)
+		switch {
+		case payloadType == "":
+			problems = append(problems, location+".PayloadType must be set to a string")
+		case payloadType == "Configuration":
+			problems = append(problems, location+".PayloadType is Configuration, profiles can't be nested")
+		case !payloadTypePattern.MatchString(payloadType):
+			problems = append(problems, fmt.Sprintf("%s.PayloadType %q is not a reverse DNS payload type such as com.apple.wifi.managed", location, payloadType))
+		}
+
+		if identifier, _ := payload["PayloadIdentifier"].(string); identifier == "" {
+			problems = append(problems, location+".PayloadIdentifier must be set to a string")
+		}
+
+		uuid, _ := payload["PayloadUUID"].(string)
+		if uuid == "" {
+			problems = append(problems, location+".PayloadUUID must be set to a string")
+			continue
+		}
+		if first, found := uuids[strings.ToUpper(uuid)]; found {
+			problems = append(problems, fmt.Sprintf("%s.PayloadUUID %s is already used by %s, every payload needs its own", location, uuid, first))
+			continue
+		}
+		uuids[strings.ToUpper(uuid)] = location
+	}
+	return problems
+}
+
+// describePlistValue names a value in messages about a key with the wrong
+// value.
+func describePlistValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nothing"
+	case string:
+		return fmt.Sprintf("%q", value)
+	case map[string]any:
+		return "a dictionary"
+	case []any:
+		return "an array"
+	case bool:
+		return "a boolean"
+	case []byte:
+		return "data"
+	case float64:
+		return "a real"
+	case time.Time:
+		return "a date"
+	}
+	return "an integer"
+}
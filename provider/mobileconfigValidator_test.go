@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func validateMobileconfig(mobileconfig types.String) []string {
+	resp := &validator.StringResponse{}
+	mobileconfigValidator{}.ValidateString(context.Background(), validator.StringRequest{
+		Path:        path.Root("mobileconfig"),
+		ConfigValue: mobileconfig,
+	}, resp)
+
+	var details []string
+	for _, diagnostic := range resp.Diagnostics.Errors() {
+		details = append(details, diagnostic.Detail())
+	}
+	return details
+}
+
+func testMobileconfigDocument(topLevel string, payloads ...string) string {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+` + topLevel + `
+	<key>PayloadContent</key>
+	<array>
+`
+	for _, payload := range payloads {
+		document += "<dict>" + payload + "</dict>\n"
+	}
+	return document + `	</array>
+</dict>
+</plist>`
+}
+
+const testMobileconfigTopLevel = `
+	<key>PayloadType</key><string>Configuration</string>
+	<key>PayloadIdentifier</key><string>com.example.profile</string>
+	<key>PayloadUUID</key><string>0E2E3E4A-0000-4000-8000-000000000000</string>`
+
+func TestMobileconfigValidatorAcceptsValidProfiles(t *testing.T) {
+	for _, file := range []string{"testfiles/testprofile.mobileconfig", "testfiles/testprofile2.mobileconfig"} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details := validateMobileconfig(types.StringValue(string(content))); len(details) != 0 {
+			t.Errorf("%s: unexpected errors %v", file, details)
+		}
+	}
+
+	if details := validateMobileconfig(types.StringUnknown()); len(details) != 0 {
+		t.Errorf("unknown values must not be validated, got %v", details)
+	}
+	if details := validateMobileconfig(types.StringNull()); len(details) != 0 {
+		t.Errorf("null values must not be validated, got %v", details)
+	}
+}
+
+func TestMobileconfigValidatorReportsProblems(t *testing.T) {
+	validPayload := `<key>PayloadType</key><string>com.apple.wifi.managed</string>
+		<key>PayloadIdentifier</key><string>com.example.wifi</string>
+		<key>PayloadUUID</key><string>11111111-0000-4000-8000-000000000000</string>`
+
+	tests := map[string]struct {
+		mobileconfig string
+		expect       []string
+	}{
+		"not a plist": {
+			mobileconfig: "<plist><dict><key>PayloadType</key></dict></plist>",
+			expect:       []string{`the property list can't be parsed, line 1: key "PayloadType" has no value`},
+		},
+		"missing top level keys": {
+			mobileconfig: `<plist><dict><key>PayloadType</key><integer>1</integer></dict></plist>`,
+			expect: []string{
+				"the top level PayloadType must be Configuration, got an integer",
+				"the top level PayloadIdentifier must be set to a string",
+				"the top level PayloadUUID must be set to a string",
+				"the top level PayloadContent is missing",
+			},
+		},
+		"invalid payload types": {
+			mobileconfig: testMobileconfigDocument(testMobileconfigTopLevel,
+				validPayload,
+				`<key>PayloadType</key><string>Configuration</string>
+				<key>PayloadIdentifier</key><string>com.example.nested</string>
+				<key>PayloadUUID</key><string>22222222-0000-4000-8000-000000000000</string>`,
+				`<key>PayloadType</key><string>wifi settings</string>
+				<key>PayloadIdentifier</key><string>com.example.other</string>
+				<key>PayloadUUID</key><string>33333333-0000-4000-8000-000000000000</string>`,
+				`<key>PayloadIdentifier</key><string>com.example.untyped</string>`,
+			),
+			expect: []string{
+				"PayloadContent[1].PayloadType is Configuration, profiles can't be nested",
+				`PayloadContent[2].PayloadType "wifi settings" is not a reverse DNS payload type`,
+				"PayloadContent[3].PayloadType must be set to a string",
+				"PayloadContent[3].PayloadUUID must be set to a string",
+			},
+		},
+		"duplicate payload UUIDs": {
+			mobileconfig: testMobileconfigDocument(testMobileconfigTopLevel,
+				validPayload,
+				strings.Replace(validPayload, "com.example.wifi", "com.example.wifi2", 1),
+				`<key>PayloadType</key><string>com.apple.dock</string>
+				<key>PayloadIdentifier</key><string>com.example.dock</string>
+				<key>PayloadUUID</key><string>0e2e3e4a-0000-4000-8000-000000000000</string>`,
+			),
+			expect: []string{
+				"PayloadContent[1].PayloadUUID 11111111-0000-4000-8000-000000000000 is already used by PayloadContent[0]",
+				"PayloadContent[2].PayloadUUID 0e2e3e4a-0000-4000-8000-000000000000 is already used by the profile",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			details := validateMobileconfig(types.StringValue(test.mobileconfig))
+			if len(details) != len(test.expect) {
+				t.Fatalf("expected %d errors, got %d: %v", len(test.expect), len(details), details)
+			}
+			for i, expect := range test.expect {
+				if !strings.Contains(details[i], expect) {
+					t.Errorf("expected error %d to contain %q, got %q", i, expect, details[i])
+				}
+			}
+		})
+	}
+}
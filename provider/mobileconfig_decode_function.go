@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ function.Function = &mobileconfigDecodeFunction{}
+)
+
+var mobileconfigSignerType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"subject":            types.StringType,
+	"issuer":             types.StringType,
+	"serial_number":      types.StringType,
+	"not_before":         types.StringType,
+	"not_after":          types.StringType,
+	"sha256_fingerprint": types.StringType,
+}}
+
+// MobileconfigDecodeFunction is a helper function to simplify the provider implementation.
+func MobileconfigDecodeFunction() function.Function {
+	return &mobileconfigDecodeFunction{}
+}
+
+// mobileconfigDecodeFunction turns a configuration profile into an object.
+type mobileconfigDecodeFunction struct{}
+
+// Metadata returns the function name.
+func (f *mobileconfigDecodeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "mobileconfig_decode"
+}
+
+// Definition defines the parameters and return type of the function.
+func (f *mobileconfigDecodeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Decodes a configuration profile (mobileconfig) into an object.",
+		MarkdownDescription: "Decodes a configuration profile into an object, the reverse of the `mobileconfig` function. " +
+			"XML profiles can be passed as text, for example with `file()`. Binary property lists and signed profiles have to be passed base64 encoded with `filebase64()`.\n\n" +
+			"The result has three attributes: `profile` holds the decoded profile, `signed` tells whether it was wrapped in a CMS signature " +
+			"and `signers` lists the certificates which signed it with their `subject`, `issuer`, `serial_number`, `not_before`, `not_after` and `sha256_fingerprint`. " +
+			"The signature itself is not verified. Devices check it when they install the profile, and editing a decoded profile and rendering it again drops it.\n\n" +
+			"Values are decoded the way `mobileconfig` expects them, so `provider::simplemdm::mobileconfig(merge(decoded.profile, { ... }))` renders the profile again. " +
+			"`<data>` becomes `{ \"$data\" = \"<base64>\" }`, `<date>` becomes `{ \"$date\" = \"<RFC 3339 timestamp>\" }` and a `<real>` holding a whole number becomes `{ \"$real\" = n }`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "mobileconfig",
+				MarkdownDescription: "XML property list, or the base64 encoding of an XML or binary property list or of a signed profile.",
+			},
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+// Run decodes the profile.
+func (f *mobileconfigDecodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var mobileconfig string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &mobileconfig))
+	if resp.Error != nil {
+		return
+	}
+
+	content, signers, err := readMobileconfig(mobileconfig)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+	value, err := decodePlist(content)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+	if _, ok := value.(map[string]any); !ok {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: the property list doesn't hold a dictionary.")
+		return
+	}
+	profile, err := terraformValueFromPlist(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+
+	signerValues := make([]attr.Value, 0, len(signers))
+	for _, signer := range signers {
+		signerValues = append(signerValues, types.ObjectValueMust(mobileconfigSignerType.AttrTypes, map[string]attr.Value{
+			"subject":            types.StringValue(signer.Subject),
+			"issuer":             types.StringValue(signer.Issuer),
+			"serial_number":      types.StringValue(signer.SerialNumber),
+			"not_before":         timestampValue(signer.NotBefore),
+			"not_after":          timestampValue(signer.NotAfter),
+			"sha256_fingerprint": types.StringValue(signer.SHA256Fingerprint),
+		}))
+	}
+
+	result := types.ObjectValueMust(
+		map[string]attr.Type{
+			"profile": profile.Type(ctx),
+			"signed":  types.BoolType,
+			"signers": types.ListType{ElemType: mobileconfigSignerType},
+		},
+		map[string]attr.Value{
+			"profile": profile,
+			"signed":  types.BoolValue(signers != nil),
+			"signers": types.ListValueMust(mobileconfigSignerType, signerValues),
+		},
+	)
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, types.DynamicValue(result)))
+}
+
+// readMobileconfig returns the property list held by the argument and, for
+// signed profiles, their signers. signers is nil for unsigned profiles.
+func readMobileconfig(mobileconfig string) ([]byte, []cmsSigner, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(mobileconfig, "\ufeff"))
+	if strings.HasPrefix(trimmed, "<") || strings.HasPrefix(trimmed, binaryPlistMagic) {
+		return []byte(trimmed), nil, nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(trimmed), ""))
+	if err != nil {
+		return nil, nil, fmt.Errorf("expected an XML property list or base64 encoded content, use filebase64() for binary and signed profiles")
+	}
+	if !looksLikeCMS(content) {
+		return content, nil, nil
+	}
+	return unwrapCMS(content)
+}
+
+func timestampValue(t time.Time) types.String {
+	if t.IsZero() {
+		return types.StringNull()
+	}
+	return types.StringValue(t.Format(time.RFC3339))
+}
+
+// terraformValueFromPlist converts the plist values described in plist.go
+// to Terraform values, using the type markers of the mobileconfig function
+// for data, dates and whole number reals.
+func terraformValueFromPlist(value any) (attr.Value, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		attributeTypes := make(map[string]attr.Type, len(value))
+		attributes := make(map[string]attr.Value, len(value))
+		for key, element := range value {
+			converted, err := terraformValueFromPlist(element)
+			if err != nil {
+				return nil, err
+			}
+			attributeTypes[key] = converted.Type(context.Background())
+			attributes[key] = converted
+		}
+		return types.ObjectValueMust(attributeTypes, attributes), nil
+
+	case []any:
+		elementTypes := make([]attr.Type, 0, len(value))
+		elements := make([]attr.Value, 0, len(value))
+		for _, element := range value {
+			converted, err := terraformValueFromPlist(element)
+			if err != nil {
+				return nil, err
+			}
+			elementTypes = append(elementTypes, converted.Type(context.Background()))
+			elements = append(elements, converted)
+		}
+		return types.TupleValueMust(elementTypes, elements), nil
+
+	case string:
+		return types.StringValue(value), nil
+
+	case bool:
+		return types.BoolValue(value), nil
+
+	case int64:
+		return types.NumberValue(new(big.Float).SetInt64(value)), nil
+
+	case uint64:
+		return types.NumberValue(new(big.Float).SetUint64(value)), nil
+
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("the real %v can't be represented in Terraform", value)
+		}
+		number := types.NumberValue(big.NewFloat(value))
+		if value == math.Trunc(value) {
+			return plistMarkerValue(plistRealKey, number), nil
+		}
+		return number, nil
+
+	case []byte:
+		return plistMarkerValue(plistDataKey, types.StringValue(base64.StdEncoding.EncodeToString(value))), nil
+
+	case time.Time:
+		return plistMarkerValue(plistDateKey, types.StringValue(value.UTC().Format(time.RFC3339))), nil
+	}
+	return nil, fmt.Errorf("unsupported property list value %T", value)
+}
+
+func plistMarkerValue(key string, value attr.Value) attr.Value {
+	return types.ObjectValueMust(map[string]attr.Type{key: value.Type(context.Background())}, map[string]attr.Value{key: value})
+}
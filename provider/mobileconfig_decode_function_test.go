@@ -0,0 +1,355 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func runMobileconfigDecodeFunction(t *testing.T, mobileconfig string) (map[string]attr.Value, *function.FuncError) {
+	t.Helper()
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicUnknown())}
+	MobileconfigDecodeFunction().Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(mobileconfig)})}, resp)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result.Value().(types.Dynamic).UnderlyingValue().(types.Object).Attributes(), nil
+}
+
+func TestMobileconfigDecodeFunctionRoundTrip(t *testing.T) {
+	noSeed := types.TupleValueMust([]attr.Type{}, []attr.Value{})
+	rendered, funcErr := runMobileconfigFunction(t, testMobileconfigProfile(), noSeed)
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+
+	decoded, funcErr := runMobileconfigDecodeFunction(t, rendered)
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	if decoded["signed"].(types.Bool).ValueBool() || len(decoded["signers"].(types.List).Elements()) != 0 {
+		t.Error("expected an unsigned profile")
+	}
+
+	again, funcErr := runMobileconfigFunction(t, types.DynamicValue(decoded["profile"]), noSeed)
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	if again != rendered {
+		t.Errorf("expected the decoded profile to render the same document, got:\n%s\nexpected:\n%s", again, rendered)
+	}
+
+	// filebase64() of the same document decodes the same way.
+	encoded, funcErr := runMobileconfigDecodeFunction(t, base64.StdEncoding.EncodeToString([]byte(rendered)))
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	if !encoded["profile"].Equal(decoded["profile"]) {
+		t.Error("expected the base64 encoded document to decode to the same profile")
+	}
+}
+
+// testBinaryPlist assembles a binary property list from encoded objects,
+// the first one is the top object and references are single bytes.
+func testBinaryPlist(objects ...[]byte) []byte {
+	content := []byte(binaryPlistMagic)
+	offsets := []byte{}
+	for _, object := range objects {
+		offsets = append(offsets, byte(len(content)))
+		content = append(content, object...)
+	}
+	tableOffset := len(content)
+	content = append(content, offsets...)
+
+	trailer := make([]byte, 32)
+	trailer[6] = 1
+	trailer[7] = 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	return append(content, trailer...)
+}
+
+func testBinaryPlistString(text string) []byte {
+	if len(text) < 15 {
+		return append([]byte{0x50 | byte(len(text))}, text...)
+	}
+	return append([]byte{0x5f, 0x10, byte(len(text))}, text...)
+}
+
+func TestMobileconfigDecodeFunctionBinary(t *testing.T) {
+	scale := make([]byte, 9)
+	scale[0] = 0x23
+	binary.BigEndian.PutUint64(scale[1:], math.Float64bits(1.5))
+	date := make([]byte, 9)
+	date[0] = 0x33
+	binary.BigEndian.PutUint64(date[1:], math.Float64bits(86400))
+
+	content := testBinaryPlist(
+		[]byte{0xd7, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14},
+		testBinaryPlistString("PayloadIdentifier"),
+		testBinaryPlistString("Count"),
+		testBinaryPlistString("Blob"),
+		testBinaryPlistString("Scale"),
+		testBinaryPlistString("Enabled"),
+		testBinaryPlistString("Expires"),
+		testBinaryPlistString("Names"),
+		testBinaryPlistString("com.example.binary"),
+		[]byte{0x11, 0x01, 0x00},
+		[]byte{0x42, 'h', 'i'},
+		scale,
+		[]byte{0x09},
+		date,
+		[]byte{0xa1, 15},
+		[]byte{0x61, 0x00, 0xe9},
+	)
+
+	decoded, funcErr := runMobileconfigDecodeFunction(t, base64.StdEncoding.EncodeToString(content))
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	profile := decoded["profile"].(types.Object).Attributes()
+
+	marker := func(key string, value attr.Value) attr.Value {
+		return types.ObjectValueMust(map[string]attr.Type{key: value.Type(context.Background())}, map[string]attr.Value{key: value})
+	}
+	expected := map[string]attr.Value{
+		"PayloadIdentifier": types.StringValue("com.example.binary"),
+		"Count":             types.NumberValue(big.NewFloat(256)),
+		"Blob":              marker(plistDataKey, types.StringValue("aGk=")),
+		"Scale":             types.NumberValue(big.NewFloat(1.5)),
+		"Enabled":           types.BoolValue(true),
+		"Expires":           marker(plistDateKey, types.StringValue("2001-01-02T00:00:00Z")),
+		"Names":             types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("é")}),
+	}
+	if len(profile) != len(expected) {
+		t.Fatalf("expected %d keys, got %v", len(expected), profile)
+	}
+	for key, value := range expected {
+		if !profile[key].Equal(value) {
+			t.Errorf("%s: expected %s, got %s", key, value, profile[key])
+		}
+	}
+}
+
+// testSignedProfile wraps content in a CMS signed data structure like the
+// ones profile signing tools produce, with a self-signed certificate. The
+// content is split in two chunks of a constructed octet string.
+func testSignedProfile(t *testing.T, content []byte) ([]byte, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1234),
+		Subject:      pkix.Name{CommonName: "Example Profile Signer", Organization: []string{"Example"}},
+		NotBefore:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustMarshal := func(value any, params string) []byte {
+		encoded, err := asn1.MarshalWithParams(value, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+	tagged := func(inner []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner}
+	}
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}}
+
+	half := len(content) / 2
+	chunks := append(mustMarshal(content[:half], ""), mustMarshal(content[half:], "")...)
+	octets := mustMarshal(asn1.RawValue{Tag: asn1.TagOctetString, IsCompound: true, Bytes: chunks}, "")
+
+	signerID := mustMarshal(cmsIssuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: certificate.RawIssuer},
+		SerialNumber: certificate.SerialNumber,
+	}, "")
+	signed := struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		EncapContentInfo struct {
+			ContentType asn1.ObjectIdentifier
+			Content     asn1.RawValue
+		}
+		Certificates asn1.RawValue
+		SignerInfos  []cmsSignerInfo `asn1:"set"`
+	}{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{FullBytes: mustMarshal([]pkix.AlgorithmIdentifier{sha256Algorithm}, "set")},
+		Certificates:     tagged(certificate.Raw),
+		SignerInfos: []cmsSignerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: signerID},
+			DigestAlgorithm:    sha256Algorithm,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          []byte("not checked"),
+		}},
+	}
+	signed.EncapContentInfo.ContentType = oidCMSData
+	signed.EncapContentInfo.Content = tagged(octets)
+
+	return mustMarshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidCMSSignedData, tagged(mustMarshal(signed, ""))}, ""), certificate
+}
+
+func TestMobileconfigDecodeFunctionSigned(t *testing.T) {
+	noSeed := types.TupleValueMust([]attr.Type{}, []attr.Value{})
+	rendered, funcErr := runMobileconfigFunction(t, testMobileconfigProfile(), noSeed)
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	signedProfile, certificate := testSignedProfile(t, []byte(rendered))
+
+	decoded, funcErr := runMobileconfigDecodeFunction(t, base64.StdEncoding.EncodeToString(signedProfile))
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	if !decoded["signed"].(types.Bool).ValueBool() {
+		t.Error("expected the profile to be reported as signed")
+	}
+	unsigned, _ := runMobileconfigDecodeFunction(t, rendered)
+	if !decoded["profile"].Equal(unsigned["profile"]) {
+		t.Error("expected the signed profile to decode to the same profile")
+	}
+
+	signers := decoded["signers"].(types.List).Elements()
+	if len(signers) != 1 {
+		t.Fatalf("expected one signer, got %d", len(signers))
+	}
+	fingerprint := sha256.Sum256(certificate.Raw)
+	expected := map[string]string{
+		"subject":            "CN=Example Profile Signer,O=Example",
+		"issuer":             "CN=Example Profile Signer,O=Example",
+		"serial_number":      "1234",
+		"not_before":         "2024-01-01T00:00:00Z",
+		"not_after":          "2034-01-01T00:00:00Z",
+		"sha256_fingerprint": hex.EncodeToString(fingerprint[:]),
+	}
+	attributes := signers[0].(types.Object).Attributes()
+	for key, value := range expected {
+		if got := attributes[key].(types.String).ValueString(); got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestMobileconfigDecodeFunctionErrors(t *testing.T) {
+	tests := map[string]struct {
+		mobileconfig string
+		expect       string
+	}{
+		"not a plist": {
+			mobileconfig: "PayloadIdentifier = x",
+			expect:       "use filebase64()",
+		},
+		"malformed XML": {
+			mobileconfig: "<plist><dict><key>A</key></dict></plist>",
+			expect:       `line 1: key "A" has no value`,
+		},
+		"not a dictionary": {
+			mobileconfig: "<plist><array/></plist>",
+			expect:       "doesn't hold a dictionary",
+		},
+		"truncated binary": {
+			mobileconfig: base64.StdEncoding.EncodeToString([]byte(binaryPlistMagic + "\xd1")),
+			expect:       "binary property list is truncated",
+		},
+		"self referencing binary": {
+			mobileconfig: base64.StdEncoding.EncodeToString(testBinaryPlist([]byte{0xa1, 0})),
+			expect:       "contains itself",
+		},
+		"not signed data": {
+			mobileconfig: base64.StdEncoding.EncodeToString([]byte{0x30, 0x80, 0x00, 0x00}),
+			expect:       "invalid CMS structure",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := runMobileconfigDecodeFunction(t, test.mobileconfig)
+			if err == nil || !strings.Contains(err.Error(), test.expect) {
+				t.Errorf("expected an error containing %q, got %v", test.expect, err)
+			}
+		})
+	}
+}
+
+func TestAccMobileconfigDecodeFunction(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				locals {
+					decoded = provider::simplemdm::mobileconfig_decode(<<-EOT
+						<?xml version="1.0" encoding="UTF-8"?>
+						<plist version="1.0">
+						<dict>
+							<key>PayloadIdentifier</key>
+							<string>com.example.wifi</string>
+							<key>PayloadContent</key>
+							<array>
+								<dict>
+									<key>PayloadType</key>
+									<string>com.apple.wifi.managed</string>
+									<key>SSID_STR</key>
+									<string>Office</string>
+								</dict>
+							</array>
+						</dict>
+						</plist>
+					EOT
+					)
+				}
+
+				output "ssid" {
+					value = local.decoded.profile.PayloadContent[0].SSID_STR
+				}
+
+				output "signed" {
+					value = local.decoded.signed
+				}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("ssid", "Office"),
+					resource.TestCheckOutput("signed", "false"),
+				),
+			},
+		},
+	})
+}
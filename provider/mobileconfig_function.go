@@ -0,0 +1,315 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ function.Function = &mobileconfigFunction{}
+)
+
+// Keys of the single attribute objects which mark values HCL has no type
+// for, for example { "$data" = filebase64("cert.cer") }.
+const (
+	plistDataKey    = "$data"
+	plistDateKey    = "$date"
+	plistRealKey    = "$real"
+	plistIntegerKey = "$integer"
+)
+
+// MobileconfigFunction is a helper function to simplify the provider implementation.
+func MobileconfigFunction() function.Function {
+	return &mobileconfigFunction{}
+}
+
+// mobileconfigFunction renders a configuration profile from an object.
+type mobileconfigFunction struct{}
+
+// Metadata returns the function name.
+func (f *mobileconfigFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "mobileconfig"
+}
+
+// Definition defines the parameters and return type of the function.
+func (f *mobileconfigFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Renders a configuration profile (mobileconfig) from an object.",
+		MarkdownDescription: "Renders an object describing a configuration profile as an XML property list, ready for the `mobileconfig` attribute of `simplemdm_customprofile`. " +
+			"Dictionary keys are sorted so the same object always renders to the same document.\n\n" +
+			"Strings, bools, lists and objects map to their plist counterparts, whole numbers become `<integer>` and other numbers `<real>`. " +
+			"Types HCL doesn't have are written as objects with a single key: `{ \"$data\" = filebase64(\"cert.cer\") }` for `<data>`, " +
+			"`{ \"$date\" = \"2024-01-31T12:00:00Z\" }` for `<date>`, `{ \"$real\" = 2 }` for a whole number `<real>` and `{ \"$integer\" = 3 }` for an `<integer>`. Null attributes are left out.\n\n" +
+			"`PayloadType` defaults to `Configuration` and `PayloadVersion` to `1`, for the profile and every entry of `PayloadContent`. " +
+			"Payloads without `PayloadIdentifier` get the profile identifier followed by their `PayloadType`. " +
+			"Missing `PayloadUUID`s are derived from the seed and the payload identifier, so they stay the same on every run. " +
+			"The seed defaults to the profile `PayloadIdentifier`, pass one to give copies of a profile different UUIDs.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "profile",
+				MarkdownDescription: "Object with the top level keys of the profile, at least `PayloadIdentifier`, and its payloads in `PayloadContent`.",
+			},
+		},
+		VariadicParameter: function.StringParameter{
+			Name:                "seed",
+			MarkdownDescription: "Optional seed for the generated `PayloadUUID`s, at most one.",
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+// Run renders the profile.
+func (f *mobileconfigFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var profile types.Dynamic
+	var seeds []string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &profile, &seeds))
+	if resp.Error != nil {
+		return
+	}
+	if len(seeds) > 1 {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("At most one seed can be given, got %d.", len(seeds)))
+		return
+	}
+
+	value, err := plistValueFromTerraform(profile, "")
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+	dict, ok := value.(map[string]any)
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: the profile must be an object.")
+		return
+	}
+
+	seed := ""
+	if len(seeds) == 1 {
+		seed = seeds[0]
+	}
+	if err := completeMobileconfig(dict, seed); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+
+	document, err := encodePlistXML(dict)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "Invalid profile: "+err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, string(document)))
+}
+
+// completeMobileconfig adds the keys every profile and payload needs which
+// the object left out, see the function description.
+func completeMobileconfig(profile map[string]any, seed string) error {
+	identifier, ok := profile["PayloadIdentifier"].(string)
+	if !ok || identifier == "" {
+		return fmt.Errorf("PayloadIdentifier must be set to a string")
+	}
+	if seed == "" {
+		seed = identifier
+	}
+	setPlistDefault(profile, "PayloadType", "Configuration")
+	setPlistDefault(profile, "PayloadVersion", int64(1))
+	setPlistDefault(profile, "PayloadUUID", payloadUUID(seed, ""))
+
+	content, found := profile["PayloadContent"]
+	if !found {
+		profile["PayloadContent"] = []any{}
+		return nil
+	}
+	payloads, ok := content.([]any)
+	if !ok {
+		return fmt.Errorf("PayloadContent must be a list of payload objects")
+	}
+
+	identifiers := map[string]bool{}
+	for i, element := range payloads {
+		payload, ok := element.(map[string]any)
+		if !ok {
+			return fmt.Errorf("PayloadContent[%d] must be an object", i)
+		}
+		payloadType, ok := payload["PayloadType"].(string)
+		if !ok || payloadType == "" {
+			return fmt.Errorf("PayloadContent[%d].PayloadType must be set to a string", i)
+		}
+
+		if _, found := payload["PayloadIdentifier"]; !found {
+			payloadIdentifier := identifier + "." + payloadType
+			if identifiers[payloadIdentifier] {
+				payloadIdentifier += "." + strconv.Itoa(i)
+			}
+			payload["PayloadIdentifier"] = payloadIdentifier
+		}
+		payloadIdentifier, _ := payload["PayloadIdentifier"].(string)
+		identifiers[payloadIdentifier] = true
+
+		setPlistDefault(payload, "PayloadVersion", int64(1))
+		setPlistDefault(payload, "PayloadUUID", payloadUUID(seed, payloadIdentifier))
+	}
+	return nil
+}
+
+func setPlistDefault(dict map[string]any, key string, value any) {
+	if _, found := dict[key]; !found {
+		dict[key] = value
+	}
+}
+
+// plistValueFromTerraform converts a Terraform value to the plist values
+// described in plist.go. location names the value in errors.
+func plistValueFromTerraform(value attr.Value, location string) (any, error) {
+	if value.IsUnknown() {
+		return nil, fmt.Errorf("%s is not known yet", plistLocation(location))
+	}
+	if value.IsNull() {
+		return nil, nil
+	}
+
+	switch value := value.(type) {
+	case basetypes.DynamicValue:
+		return plistValueFromTerraform(value.UnderlyingValue(), location)
+	case basetypes.StringValue:
+		return value.ValueString(), nil
+	case basetypes.BoolValue:
+		return value.ValueBool(), nil
+	case basetypes.NumberValue:
+		return plistNumber(value.ValueBigFloat(), location)
+	case basetypes.Int64Value:
+		return value.ValueInt64(), nil
+	case basetypes.Float64Value:
+		return value.ValueFloat64(), nil
+	case basetypes.ObjectValue:
+		return plistDictFromTerraform(value.Attributes(), location)
+	case basetypes.MapValue:
+		return plistDictFromTerraform(value.Elements(), location)
+	case basetypes.ListValue:
+		return plistArrayFromTerraform(value.Elements(), location)
+	case basetypes.SetValue:
+		return plistArrayFromTerraform(value.Elements(), location)
+	case basetypes.TupleValue:
+		return plistArrayFromTerraform(value.Elements(), location)
+	}
+	return nil, fmt.Errorf("%s has the unsupported type %s", plistLocation(location), value.Type(context.Background()))
+}
+
+func plistDictFromTerraform(attributes map[string]attr.Value, location string) (any, error) {
+	if len(attributes) == 1 {
+		for key, value := range attributes {
+			if strings.HasPrefix(key, "$") {
+				return plistTypedValue(key, value, location)
+			}
+		}
+	}
+
+	dict := map[string]any{}
+	for key, element := range attributes {
+		converted, err := plistValueFromTerraform(element, joinPlistLocation(location, key))
+		if err != nil {
+			return nil, err
+		}
+		if converted != nil {
+			dict[key] = converted
+		}
+	}
+	return dict, nil
+}
+
+func plistArrayFromTerraform(elements []attr.Value, location string) (any, error) {
+	array := []any{}
+	for i, element := range elements {
+		elementLocation := fmt.Sprintf("%s[%d]", location, i)
+		converted, err := plistValueFromTerraform(element, elementLocation)
+		if err != nil {
+			return nil, err
+		}
+		if converted == nil {
+			return nil, fmt.Errorf("%s is null, property lists can't hold null values", elementLocation)
+		}
+		array = append(array, converted)
+	}
+	return array, nil
+}
+
+// plistTypedValue converts the single attribute objects marking data, dates
+// and numbers of a fixed type.
+func plistTypedValue(key string, value attr.Value, location string) (any, error) {
+	converted, err := plistValueFromTerraform(value, location)
+	if err != nil {
+		return nil, err
+	}
+	text, isString := converted.(string)
+
+	switch key {
+	case plistDataKey:
+		if !isString {
+			return nil, fmt.Errorf("%s: %s must be a base64 encoded string", plistLocation(location), key)
+		}
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s is not valid base64: %w", plistLocation(location), key, err)
+		}
+		return data, nil
+
+	case plistDateKey:
+		if !isString {
+			return nil, fmt.Errorf("%s: %s must be an RFC 3339 timestamp", plistLocation(location), key)
+		}
+		date, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s is not an RFC 3339 timestamp: %w", plistLocation(location), key, err)
+		}
+		return date.UTC(), nil
+
+	case plistRealKey:
+		switch number := converted.(type) {
+		case int64:
+			return float64(number), nil
+		case float64:
+			return number, nil
+		}
+		return nil, fmt.Errorf("%s: %s must be a number", plistLocation(location), key)
+
+	case plistIntegerKey:
+		switch number := converted.(type) {
+		case int64:
+			return number, nil
+		case string:
+			integer, err := strconv.ParseInt(number, 10, 64)
+			if err == nil {
+				return integer, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %s must be a whole number", plistLocation(location), key)
+	}
+	return nil, fmt.Errorf("%s: unknown type marker %s, use %s, %s, %s or %s", plistLocation(location), key, plistDataKey, plistDateKey, plistRealKey, plistIntegerKey)
+}
+
+// plistNumber turns whole numbers into integers and all others into reals.
+func plistNumber(number *big.Float, location string) (any, error) {
+	if number.IsInt() {
+		integer, accuracy := number.Int64()
+		if accuracy != big.Exact {
+			return nil, fmt.Errorf("%s is too large for a plist integer", plistLocation(location))
+		}
+		return integer, nil
+	}
+	float, _ := number.Float64()
+	if math.IsInf(float, 0) {
+		return nil, fmt.Errorf("%s is too large for a plist real", plistLocation(location))
+	}
+	return float, nil
+}
@@ -0,0 +1,205 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+const testMobileconfigRendered = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadContent</key>
+	<array>
+		<dict>
+			<key>Certificate</key>
+			<data>aGVsbG8=</data>
+			<key>Expires</key>
+			<date>2030-01-31T12:00:00Z</date>
+			<key>Flags</key>
+			<array>
+				<true/>
+				<false/>
+			</array>
+			<key>PayloadIdentifier</key>
+			<string>com.example.wifi.com.apple.wifi.managed</string>
+			<key>PayloadType</key>
+			<string>com.apple.wifi.managed</string>
+			<key>PayloadUUID</key>
+			<string>PAYLOAD-UUID</string>
+			<key>PayloadVersion</key>
+			<integer>1</integer>
+			<key>Ratio</key>
+			<real>2</real>
+			<key>SSID_STR</key>
+			<string>Office &amp; Lab</string>
+			<key>Scale</key>
+			<real>1.5</real>
+		</dict>
+	</array>
+	<key>PayloadDisplayName</key>
+	<string>Wi-Fi</string>
+	<key>PayloadIdentifier</key>
+	<string>com.example.wifi</string>
+	<key>PayloadType</key>
+	<string>Configuration</string>
+	<key>PayloadUUID</key>
+	<string>PROFILE-UUID</string>
+	<key>PayloadVersion</key>
+	<integer>1</integer>
+</dict>
+</plist>
+`
+
+func testMobileconfigProfile() types.Dynamic {
+	marker := func(key string, value attr.Value) attr.Value {
+		return types.ObjectValueMust(map[string]attr.Type{key: value.Type(context.Background())}, map[string]attr.Value{key: value})
+	}
+	payload := map[string]attr.Value{
+		"PayloadType": types.StringValue("com.apple.wifi.managed"),
+		"SSID_STR":    types.StringValue("Office & Lab"),
+		"Certificate": marker(plistDataKey, types.StringValue("aGVsbG8=")),
+		"Expires":     marker(plistDateKey, types.StringValue("2030-01-31T13:00:00+01:00")),
+		"Ratio":       marker(plistRealKey, types.NumberValue(big.NewFloat(2))),
+		"Scale":       types.NumberValue(big.NewFloat(1.5)),
+		"Flags":       types.TupleValueMust([]attr.Type{types.BoolType, types.BoolType}, []attr.Value{types.BoolValue(true), types.BoolValue(false)}),
+		"Unset":       types.StringNull(),
+	}
+	payloadTypes := map[string]attr.Type{}
+	for key, value := range payload {
+		payloadTypes[key] = value.Type(context.Background())
+	}
+	payloadObject := types.ObjectValueMust(payloadTypes, payload)
+
+	profile := map[string]attr.Value{
+		"PayloadIdentifier":  types.StringValue("com.example.wifi"),
+		"PayloadDisplayName": types.StringValue("Wi-Fi"),
+		"PayloadContent":     types.TupleValueMust([]attr.Type{payloadObject.Type(context.Background())}, []attr.Value{payloadObject}),
+	}
+	profileTypes := map[string]attr.Type{}
+	for key, value := range profile {
+		profileTypes[key] = value.Type(context.Background())
+	}
+	return types.DynamicValue(types.ObjectValueMust(profileTypes, profile))
+}
+
+func runMobileconfigFunction(t *testing.T, arguments ...attr.Value) (string, *function.FuncError) {
+	t.Helper()
+	resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+	MobileconfigFunction().Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData(arguments)}, resp)
+	if resp.Error != nil {
+		return "", resp.Error
+	}
+	return resp.Result.Value().(types.String).ValueString(), nil
+}
+
+func TestMobileconfigFunctionRendersProfile(t *testing.T) {
+	seeds := types.TupleValueMust([]attr.Type{}, []attr.Value{})
+	rendered, err := runMobileconfigFunction(t, testMobileconfigProfile(), seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		"PROFILE-UUID", payloadUUID("com.example.wifi", ""),
+		"PAYLOAD-UUID", payloadUUID("com.example.wifi", "com.example.wifi.com.apple.wifi.managed"),
+	).Replace(testMobileconfigRendered)
+	if rendered != expected {
+		t.Errorf("unexpected document:\n%s\nexpected:\n%s", rendered, expected)
+	}
+
+	again, _ := runMobileconfigFunction(t, testMobileconfigProfile(), seeds)
+	if again != rendered {
+		t.Error("expected the same document on every call")
+	}
+
+	seeded, err := runMobileconfigFunction(t, testMobileconfigProfile(), types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("copy")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(seeded, payloadUUID("copy", "")) {
+		t.Error("expected the seed to change the generated UUIDs")
+	}
+}
+
+func TestMobileconfigFunctionErrors(t *testing.T) {
+	noSeed := types.TupleValueMust([]attr.Type{}, []attr.Value{})
+	tests := map[string]struct {
+		profile attr.Value
+		expect  string
+	}{
+		"not an object": {
+			profile: types.DynamicValue(types.StringValue("plist")),
+			expect:  "must be an object",
+		},
+		"missing identifier": {
+			profile: types.DynamicValue(types.ObjectValueMust(map[string]attr.Type{"PayloadDisplayName": types.StringType}, map[string]attr.Value{"PayloadDisplayName": types.StringValue("x")})),
+			expect:  "PayloadIdentifier must be set",
+		},
+		"invalid data": {
+			profile: types.DynamicValue(types.ObjectValueMust(
+				map[string]attr.Type{"PayloadIdentifier": types.StringType, "Blob": types.ObjectType{AttrTypes: map[string]attr.Type{plistDataKey: types.StringType}}},
+				map[string]attr.Value{"PayloadIdentifier": types.StringValue("x"), "Blob": types.ObjectValueMust(map[string]attr.Type{plistDataKey: types.StringType}, map[string]attr.Value{plistDataKey: types.StringValue("not base64!")})},
+			)),
+			expect: "Blob: $data is not valid base64",
+		},
+		"unknown marker": {
+			profile: types.DynamicValue(types.ObjectValueMust(
+				map[string]attr.Type{"PayloadIdentifier": types.StringType, "Blob": types.ObjectType{AttrTypes: map[string]attr.Type{"$uuid": types.StringType}}},
+				map[string]attr.Value{"PayloadIdentifier": types.StringValue("x"), "Blob": types.ObjectValueMust(map[string]attr.Type{"$uuid": types.StringType}, map[string]attr.Value{"$uuid": types.StringValue("x")})},
+			)),
+			expect: "unknown type marker $uuid",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := runMobileconfigFunction(t, test.profile, noSeed)
+			if err == nil || !strings.Contains(err.Error(), test.expect) {
+				t.Errorf("expected an error containing %q, got %v", test.expect, err)
+			}
+		})
+	}
+}
+
+func TestAccMobileconfigFunction(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+				output "profile" {
+					value = provider::simplemdm::mobileconfig({
+						PayloadIdentifier  = "com.example.wifi"
+						PayloadDisplayName = "Wi-Fi"
+						PayloadContent = [{
+							PayloadType = "com.apple.wifi.managed"
+							SSID_STR    = "Office & Lab"
+							Certificate = { "$data" = "aGVsbG8=" }
+							Expires     = { "$date" = "2030-01-31T13:00:00+01:00" }
+							Ratio       = { "$real" = 2 }
+							Scale       = 1.5
+							Flags       = [true, false]
+						}]
+					})
+				}
+				`,
+				Check: resource.TestCheckOutput("profile", strings.NewReplacer(
+					"PROFILE-UUID", payloadUUID("com.example.wifi", ""),
+					"PAYLOAD-UUID", payloadUUID("com.example.wifi", "com.example.wifi.com.apple.wifi.managed"),
+				).Replace(testMobileconfigRendered)),
+			},
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Values of the on_partial_failure attribute.
+const (
+	partialFailureKeep     = "keep"
+	partialFailureRollback = "rollback"
+)
+
+// onPartialFailureAttribute is the on_partial_failure attribute of resources
+// whose create takes more than one API call, such as creating a group and
+// then assigning its devices one by one.
+func onPartialFailureAttribute(object string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+		Default:  stringdefault.StaticString(partialFailureKeep),
+		Description: "Optional. What happens when creating the " + object + " succeeds but a following step, like an assignment, fails. " +
+			"keep saves the " + object + " with everything set up so far to the state, Terraform marks it tainted and replaces it on the next apply unless you run terraform untaint to have the next apply finish the missing steps in place. " +
+			"rollback deletes the " + object + " again. Defaults to keep.",
+		Validators: []validator.String{
+			stringvalidator.OneOf(partialFailureKeep, partialFailureRollback),
+		},
+	}
+}
+
+// handlePartialCreate finishes a create which failed after the object was
+// created in SimpleMDM, the errors are already in resp. With
+// on_partial_failure = "rollback" the object is deleted, otherwise, or when
+// deleting fails, partial is saved so the object stays tracked. partial is
+// the resource model holding only what was actually set up.
+func handlePartialCreate(ctx context.Context, resp *resource.CreateResponse, mode types.String, object string, id string, partial any, rollback func() error) {
+	if mode.ValueString() == partialFailureRollback {
+		err := rollback()
+		if err == nil || isNotFound(err) {
+			return
+		}
+		resp.Diagnostics.Append(apiErrorDiagnostic(
+			"Error rolling back partially created "+object,
+			"Could not delete "+object+" "+id+", it is kept in the state instead",
+			err,
+		))
+	} else {
+		resp.Diagnostics.AddWarning(
+			"Partially created "+object+" kept in state",
+			"The "+object+" "+id+" was created in SimpleMDM but setting it up failed, see the errors. "+
+				"Only what was set up successfully is saved to the state. "+
+				"Terraform marks the "+object+" tainted and replaces it on the next apply, "+
+				"run terraform untaint to complete the setup in place instead. Set on_partial_failure = \"rollback\" to have it deleted instead.",
+		)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, partial)...)
+}
+
+// setWithout returns set without the given IDs. A null set stays null.
+func setWithout(set types.Set, ids []string) types.Set {
+	if set.IsNull() || set.IsUnknown() || len(ids) == 0 {
+		return set
+	}
+	remove := map[string]bool{}
+	for _, id := range ids {
+		remove[id] = true
+	}
+	elements := []attr.Value{}
+	for _, id := range setToStrings(set) {
+		if !remove[id] {
+			elements = append(elements, types.StringValue(id))
+		}
+	}
+	value, _ := types.SetValue(types.StringType, elements)
+	return value
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testCreate calls Create of r directly with model as the plan.
+func testCreate(t *testing.T, r resource.Resource, model any) *resource.CreateResponse {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	null := tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: null}
+	if diags := plan.Set(ctx, model); diags.HasError() {
+		t.Fatal(diags)
+	}
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: null}}
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, resp)
+	return resp
+}
+
+func mustAtoi(t *testing.T, value string) int {
+	t.Helper()
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return number
+}
+
+func testAssignmentGroupPlan(onPartialFailure string) assignment_groupResourceModel {
+	devices, _ := types.SetValueFrom(context.Background(), types.StringType, []string{"1601809", "404"})
+	return assignment_groupResourceModel{
+		Name:             types.StringValue("partial group"),
+		AutoDeploy:       types.BoolValue(true),
+		ID:               types.StringUnknown(),
+		AppsUpdate:       types.BoolValue(false),
+		AppsPush:         types.BoolValue(false),
+		Profiles:         types.SetNull(types.StringType),
+		ProfilesSync:     types.BoolValue(false),
+		Devices:          devices,
+		Attributes:       types.MapNull(types.StringType),
+		Priority:         types.Int64Value(0),
+		AppTrackLocation: types.BoolValue(true),
+		OnPartialFailure: types.StringValue(onPartialFailure),
+	}
+}
+
+func TestAssignmentGroupCreateKeepsPartialState(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	r := &assignment_groupResource{client: fake.client()}
+
+	resp := testCreate(t, r, testAssignmentGroupPlan(partialFailureKeep))
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("expected the assignment error and a warning, got %v", resp.Diagnostics)
+	}
+	var state assignment_groupResourceModel
+	if diags := resp.State.Get(context.Background(), &state); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if devices := setToStrings(state.Devices); len(devices) != 1 || devices[0] != "1601809" {
+		t.Errorf("expected only the assigned device in the state, got %v", devices)
+	}
+	if state.ID.IsUnknown() || fake.groups[mustAtoi(t, state.ID.ValueString())] == nil {
+		t.Errorf("expected the created group %s to be tracked and kept", state.ID)
+	}
+}
+
+func TestAssignmentGroupCreateRollsBack(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	r := &assignment_groupResource{client: fake.client()}
+	groups := len(fake.groups)
+
+	resp := testCreate(t, r, testAssignmentGroupPlan(partialFailureRollback))
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected the assignment error")
+	}
+	if !resp.State.Raw.IsNull() {
+		t.Errorf("expected no state after the rollback, got %v", resp.State.Raw)
+	}
+	if len(fake.groups) != groups {
+		t.Errorf("expected the group to be deleted again, got %d groups", len(fake.groups))
+	}
+}
+
+func TestDeviceCreateKeepsPartialState(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	r := &deviceResource{client: fake.client()}
+
+	profiles, _ := types.SetValueFrom(context.Background(), types.StringType, []string{"172801", "404"})
+	resp := testCreate(t, r, deviceResourceModel{
+		Name:             types.StringValue("partial device"),
+		ID:               types.StringUnknown(),
+		Attributes:       types.MapNull(types.StringType),
+		Profiles:         profiles,
+		DeviceGroups:     types.SetNull(types.StringType),
+		DeviceName:       types.StringNull(),
+		EnrollmentURL:    types.StringUnknown(),
+		OnPartialFailure: types.StringValue(partialFailureKeep),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected the profile assignment error")
+	}
+	var state deviceResourceModel
+	if diags := resp.State.Get(context.Background(), &state); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := setToStrings(state.Profiles); len(got) != 1 || got[0] != "172801" {
+		t.Errorf("expected only the assigned profile in the state, got %v", got)
+	}
+	if fake.devices[mustAtoi(t, state.ID.ValueString())] == nil {
+		t.Errorf("expected the device %s to be kept", state.ID)
+	}
+}
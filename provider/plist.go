@@ -0,0 +1,396 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Property lists are handled as plain Go values:
+//
+//	dict     map[string]any
+//	array    []any
+//	string   string
+//	integer  int64, uint64 for values above the int64 range
+//	real     float64
+//	boolean  bool
+//	data     []byte
+//	date     time.Time
+//
+// Dictionaries are always written with their keys sorted so the same value
+// renders to the same document.
+
+const plistXMLDoctype = `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">`
+
+// plistDateFormat is the format of <date> elements, always in UTC.
+const plistDateFormat = "2006-01-02T15:04:05Z"
+
+// encodePlistXML renders value as an XML property list document.
+func encodePlistXML(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(plistXMLDoctype + "\n")
+	buf.WriteString(`<plist version="1.0">` + "\n")
+	if err := writePlistXML(&buf, value, 0, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</plist>\n")
+	return buf.Bytes(), nil
+}
+
+// writePlistXML writes one value indented by depth tabs, location names the
+// value in errors.
+func writePlistXML(buf *bytes.Buffer, value any, depth int, location string) error {
+	indent := strings.Repeat("\t", depth)
+
+	switch value := value.(type) {
+	case map[string]any:
+		if len(value) == 0 {
+			buf.WriteString(indent + "<dict/>\n")
+			return nil
+		}
+		buf.WriteString(indent + "<dict>\n")
+		for _, key := range sortedPlistKeys(value) {
+			escaped, err := escapePlistText(key)
+			if err != nil {
+				return fmt.Errorf("%s: key %q %w", plistLocation(location), key, err)
+			}
+			buf.WriteString(indent + "\t<key>" + escaped + "</key>\n")
+			if err := writePlistXML(buf, value[key], depth+1, joinPlistLocation(location, key)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</dict>\n")
+
+	case []any:
+		if len(value) == 0 {
+			buf.WriteString(indent + "<array/>\n")
+			return nil
+		}
+		buf.WriteString(indent + "<array>\n")
+		for i, element := range value {
+			if err := writePlistXML(buf, element, depth+1, fmt.Sprintf("%s[%d]", location, i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</array>\n")
+
+	case string:
+		escaped, err := escapePlistText(value)
+		if err != nil {
+			return fmt.Errorf("%s %w", plistLocation(location), err)
+		}
+		buf.WriteString(indent + "<string>" + escaped + "</string>\n")
+
+	case int64:
+		buf.WriteString(indent + "<integer>" + strconv.FormatInt(value, 10) + "</integer>\n")
+
+	case uint64:
+		buf.WriteString(indent + "<integer>" + strconv.FormatUint(value, 10) + "</integer>\n")
+
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("%s is not a finite number", plistLocation(location))
+		}
+		buf.WriteString(indent + "<real>" + strconv.FormatFloat(value, 'g', -1, 64) + "</real>\n")
+
+	case bool:
+		if value {
+			buf.WriteString(indent + "<true/>\n")
+		} else {
+			buf.WriteString(indent + "<false/>\n")
+		}
+
+	case []byte:
+		buf.WriteString(indent + "<data>" + base64.StdEncoding.EncodeToString(value) + "</data>\n")
+
+	case time.Time:
+		buf.WriteString(indent + "<date>" + value.UTC().Format(plistDateFormat) + "</date>\n")
+
+	default:
+		return fmt.Errorf("%s has the unsupported type %T", plistLocation(location), value)
+	}
+	return nil
+}
+
+func sortedPlistKeys(dict map[string]any) []string {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePlistText escapes the characters XML reserves, plist writers leave
+// quotes and line breaks alone. Characters XML 1.0 can't represent at all
+// are an error.
+func escapePlistText(text string) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", fmt.Errorf("is not valid UTF-8")
+	}
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r', r == 0xFFFE, r == 0xFFFF:
+			return "", fmt.Errorf("contains the character %U which XML can't represent", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+func plistLocation(location string) string {
+	if location == "" {
+		return "the profile"
+	}
+	return location
+}
+
+func joinPlistLocation(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
+
+// payloadUUID derives a version 5 style UUID from seed and name, the same
+// inputs always give the same UUID so re-rendering a profile doesn't change
+// it.
+func payloadUUID(seed, name string) string {
+	// Namespace of the UUIDs this provider generates.
+	namespace := []byte{0x5c, 0x1e, 0x8a, 0x52, 0x3b, 0x6d, 0x4f, 0x0c, 0x9a, 0x41, 0x7d, 0x2e, 0x61, 0x0b, 0xf3, 0x94}
+	hash := sha1.New()
+	hash.Write(namespace)
+	hash.Write([]byte(seed + "\x00" + name))
+	sum := hash.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return strings.ToUpper(fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]))
+}
+
+// decodePlist parses an XML or binary property list.
+func decodePlist(content []byte) (any, error) {
+	if bytes.HasPrefix(content, []byte(binaryPlistMagic)) {
+		return decodeBinaryPlist(content)
+	}
+	return decodePlistXML(content)
+}
+
+// decodePlistXML parses an XML property list document.
+func decodePlistXML(content []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = true
+
+	var value any
+	found := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, plistXMLError(decoder, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "plist" {
+			continue
+		}
+		if found {
+			return nil, plistXMLError(decoder, fmt.Errorf("the document holds more than one value"))
+		}
+		value, err = readPlistXMLValue(decoder, start)
+		if err != nil {
+			return nil, err
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("the document is not a property list")
+	}
+	return value, nil
+}
+
+func readPlistXMLValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]any{}
+		for {
+			token, err := nextPlistXMLElement(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if token == nil {
+				return dict, nil
+			}
+			if token.Name.Local != "key" {
+				return nil, plistXMLError(decoder, fmt.Errorf("expected <key> in <dict>, got <%s>", token.Name.Local))
+			}
+			key, err := readPlistXMLText(decoder)
+			if err != nil {
+				return nil, err
+			}
+			valueStart, err := nextPlistXMLElement(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if valueStart == nil {
+				return nil, plistXMLError(decoder, fmt.Errorf("key %q has no value", key))
+			}
+			value, err := readPlistXMLValue(decoder, *valueStart)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = value
+		}
+
+	case "array":
+		array := []any{}
+		for {
+			token, err := nextPlistXMLElement(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if token == nil {
+				return array, nil
+			}
+			value, err := readPlistXMLValue(decoder, *token)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, plistXMLError(decoder, err)
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	text, err := readPlistXMLText(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return parsePlistInteger(strings.TrimSpace(text), decoder)
+	case "real":
+		value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, plistXMLError(decoder, fmt.Errorf("invalid <real> %q", text))
+		}
+		return value, nil
+	case "data":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, plistXMLError(decoder, fmt.Errorf("invalid base64 in <data>: %w", err))
+		}
+		return data, nil
+	case "date":
+		date, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, plistXMLError(decoder, fmt.Errorf("invalid <date> %q", text))
+		}
+		return date.UTC(), nil
+	}
+	return nil, plistXMLError(decoder, fmt.Errorf("unknown element <%s>", start.Name.Local))
+}
+
+func parsePlistInteger(text string, decoder *xml.Decoder) (any, error) {
+	base := 10
+	digits := text
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(strings.TrimPrefix(digits, "-"), "+")
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		base = 16
+		digits = digits[2:]
+	}
+	unsigned, err := strconv.ParseUint(digits, base, 64)
+	switch {
+	case err != nil:
+		return nil, plistXMLError(decoder, fmt.Errorf("invalid <integer> %q", text))
+	case negative && unsigned > 1<<63:
+		return nil, plistXMLError(decoder, fmt.Errorf("<integer> %q is out of range", text))
+	case negative:
+		return -int64(unsigned), nil
+	case unsigned > math.MaxInt64:
+		return unsigned, nil
+	}
+	return int64(unsigned), nil
+}
+
+// nextPlistXMLElement returns the next child element, or nil once the
+// current element ends.
+func nextPlistXMLElement(decoder *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, plistXMLError(decoder, unexpectedEOF(err))
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			return &token, nil
+		case xml.EndElement:
+			return nil, nil
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				return nil, plistXMLError(decoder, fmt.Errorf("unexpected text %q", strings.TrimSpace(string(token))))
+			}
+		}
+	}
+}
+
+// readPlistXMLText returns the text up to the end of the current element.
+func readPlistXMLText(decoder *xml.Decoder) (string, error) {
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", plistXMLError(decoder, unexpectedEOF(err))
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			text.Write(token)
+		case xml.StartElement:
+			return "", plistXMLError(decoder, fmt.Errorf("unexpected <%s>", token.Name.Local))
+		case xml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func plistXMLError(decoder *xml.Decoder, err error) error {
+	line, _ := decoder.InputPos()
+	return fmt.Errorf("line %d: %w", line, err)
+}
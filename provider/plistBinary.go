@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+const binaryPlistMagic = "bplist00"
+
+// binaryPlistEpoch is the reference date of binary plist dates.
+var binaryPlistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// errBinaryPlistTruncated is returned when an offset points past the end of
+// the document.
+var errBinaryPlistTruncated = errors.New("binary property list is truncated")
+
+// binaryPlist is a binary property list (bplist00) being decoded.
+type binaryPlist struct {
+	content       []byte
+	offsets       []uint64
+	objectRefSize int
+	// decoding holds the objects on the current path, a reference back to
+	// one of them would recurse forever.
+	decoding map[uint64]bool
+}
+
+// decodeBinaryPlist parses a binary property list, the format plutil and
+// Apple Configurator write by default.
+func decodeBinaryPlist(content []byte) (any, error) {
+	if len(content) < len(binaryPlistMagic)+32 {
+		return nil, errBinaryPlistTruncated
+	}
+
+	trailer := content[len(content)-32:]
+	offsetSize := int(trailer[6])
+	objectRefSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	offsetTableOffset := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetSize < 1 || offsetSize > 8 || objectRefSize < 1 || objectRefSize > 8 {
+		return nil, fmt.Errorf("binary property list has invalid integer sizes %d and %d", offsetSize, objectRefSize)
+	}
+	tableEnd := uint64(len(content) - 32)
+	if numObjects == 0 || topObject >= numObjects || offsetTableOffset > tableEnd || numObjects > (tableEnd-offsetTableOffset)/uint64(offsetSize) {
+		return nil, errBinaryPlistTruncated
+	}
+
+	plist := &binaryPlist{
+		content:       content[:tableEnd],
+		offsets:       make([]uint64, numObjects),
+		objectRefSize: objectRefSize,
+		decoding:      map[uint64]bool{},
+	}
+	for i := range plist.offsets {
+		start := offsetTableOffset + uint64(i*offsetSize)
+		plist.offsets[i] = readBigEndian(content[start : start+uint64(offsetSize)])
+	}
+	return plist.object(topObject)
+}
+
+func (p *binaryPlist) object(ref uint64) (any, error) {
+	if ref >= uint64(len(p.offsets)) {
+		return nil, fmt.Errorf("binary property list refers to the missing object %d", ref)
+	}
+	if p.decoding[ref] {
+		return nil, fmt.Errorf("binary property list object %d contains itself", ref)
+	}
+	p.decoding[ref] = true
+	defer delete(p.decoding, ref)
+
+	offset := p.offsets[ref]
+	if offset >= uint64(len(p.content)) {
+		return nil, errBinaryPlistTruncated
+	}
+	marker := p.content[offset]
+	kind, info := marker>>4, marker&0x0f
+	offset++
+
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		}
+		return nil, fmt.Errorf("binary property list object %d has the unsupported marker 0x%02x", ref, marker)
+
+	case 0x1:
+		size := uint64(1) << info
+		data, err := p.bytes(offset, size)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 1, 2, 4, 8:
+			// Only 8 byte integers are signed, the shorter ones always fit.
+			return int64(readBigEndian(data)), nil
+		case 16:
+			// 128 bit integers only carry unsigned 64 bit values.
+			return readBigEndian(data[8:]), nil
+		}
+		return nil, fmt.Errorf("binary property list object %d has an integer of %d bytes", ref, size)
+
+	case 0x2:
+		size := uint64(1) << info
+		data, err := p.bytes(offset, size)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+		}
+		return nil, fmt.Errorf("binary property list object %d has a real of %d bytes", ref, size)
+
+	case 0x3:
+		data, err := p.bytes(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		seconds := math.Float64frombits(binary.BigEndian.Uint64(data))
+		return binaryPlistEpoch.Add(time.Duration(seconds * float64(time.Second))), nil
+
+	case 0x4, 0x5, 0x6:
+		count, offset, err := p.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 0x4:
+			data, err := p.bytes(offset, count)
+			if err != nil {
+				return nil, err
+			}
+			return append([]byte{}, data...), nil
+		case 0x5:
+			data, err := p.bytes(offset, count)
+			if err != nil {
+				return nil, err
+			}
+			return string(data), nil
+		}
+		data, err := p.bytes(offset, count*2)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]uint16, count)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+
+	case 0xA, 0xC:
+		count, offset, err := p.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(offset, count)
+		if err != nil {
+			return nil, err
+		}
+		array := make([]any, 0, len(refs))
+		for _, elementRef := range refs {
+			element, err := p.object(elementRef)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		return array, nil
+
+	case 0xD:
+		count, offset, err := p.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(offset, count*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]any, count)
+		for i := uint64(0); i < count; i++ {
+			key, err := p.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("binary property list object %d has a dictionary key which is not a string", ref)
+			}
+			value, err := p.object(refs[count+i])
+			if err != nil {
+				return nil, err
+			}
+			dict[keyString] = value
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("binary property list object %d has the unsupported marker 0x%02x", ref, marker)
+}
+
+// count reads the length of data, strings and collections, lengths of 15 and
+// more follow the marker as an integer object.
+func (p *binaryPlist) count(info byte, offset uint64) (uint64, uint64, error) {
+	if info != 0x0f {
+		return uint64(info), offset, nil
+	}
+	marker, err := p.bytes(offset, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marker[0]>>4 != 0x1 || marker[0]&0x0f > 3 {
+		return 0, 0, fmt.Errorf("binary property list has an invalid length marker 0x%02x", marker[0])
+	}
+	size := uint64(1) << (marker[0] & 0x0f)
+	data, err := p.bytes(offset+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	count := readBigEndian(data)
+	if count > uint64(len(p.content)) {
+		return 0, 0, errBinaryPlistTruncated
+	}
+	return count, offset + 1 + size, nil
+}
+
+func (p *binaryPlist) refs(offset, count uint64) ([]uint64, error) {
+	data, err := p.bytes(offset, count*uint64(p.objectRefSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, count)
+	for i := range refs {
+		refs[i] = readBigEndian(data[i*p.objectRefSize : (i+1)*p.objectRefSize])
+	}
+	return refs, nil
+}
+
+func (p *binaryPlist) bytes(offset, size uint64) ([]byte, error) {
+	if offset > uint64(len(p.content)) || size > uint64(len(p.content))-offset {
+		return nil, errBinaryPlistTruncated
+	}
+	return p.content[offset : offset+size], nil
+}
+
+func readBigEndian(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
@@ -0,0 +1,484 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// predicateValidator checks at plan time that a string attribute holds an
+// NSPredicate format string, the way devices parse activation predicates.
+// Mistakes otherwise only show up as declarations that never activate.
+type predicateValidator struct{}
+
+func (v predicateValidator) Description(_ context.Context) string {
+	return "value must be a predicate format string such as @status(device.model.family) == \"iPhone\""
+}
+
+func (v predicateValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v predicateValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	// An empty predicate means the activation always applies.
+	predicate := req.ConfigValue.ValueString()
+	if strings.TrimSpace(predicate) == "" {
+		return
+	}
+	if err := parsePredicate(predicate); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Activation Predicate",
+			fmt.Sprintf("Attribute %s is not a valid predicate: %s.", req.Path, err),
+		)
+	}
+}
+
+// predicateSyntaxError is a syntax error in a predicate, Column counts the
+// characters of the predicate starting at 1.
+type predicateSyntaxError struct {
+	Column  int
+	Message string
+}
+
+func (e *predicateSyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Message, e.Column)
+}
+
+type predicateTokenKind int
+
+const (
+	predicateEnd predicateTokenKind = iota
+	predicateIdentifier
+	predicateString
+	predicateNumber
+	predicateVariable
+	predicateKey
+	predicatePunctuation
+)
+
+// predicateToken is a token of a predicate, keywords are identifiers.
+type predicateToken struct {
+	kind   predicateTokenKind
+	text   string
+	column int
+}
+
+// is reports whether the token is the punctuation or keyword text, keywords
+// are matched without case like NSPredicate does.
+func (t predicateToken) is(texts ...string) bool {
+	if t.kind != predicatePunctuation && t.kind != predicateIdentifier {
+		return false
+	}
+	for _, text := range texts {
+		if strings.EqualFold(t.text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t predicateToken) String() string {
+	if t.kind == predicateEnd {
+		return "the end of the predicate"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// predicateOperators lists the punctuation of predicates, longest first so
+// that <= is not read as <.
+var predicateOperators = []string{
+	"**", "==", "!=", "<>", "<=", "=<", ">=", "=>", "&&", "||",
+	"=", "<", ">", "!", "(", ")", "{", "}", "[", "]", ",", ".", "+", "-", "*", "/",
+}
+
+// predicateComparisons are the comparison operators of predicates.
+var predicateComparisons = []string{
+	"==", "=", "!=", "<>", "<=", "=<", ">=", "=>", "<", ">",
+	"BEGINSWITH", "ENDSWITH", "CONTAINS", "LIKE", "MATCHES", "IN", "BETWEEN",
+}
+
+// predicateReserved are keywords which are not key paths.
+var predicateReserved = []string{
+	"AND", "OR", "NOT", "ANY", "ALL", "NONE", "SOME",
+	"BEGINSWITH", "ENDSWITH", "CONTAINS", "LIKE", "MATCHES", "IN", "BETWEEN",
+	"TRUEPREDICATE", "FALSEPREDICATE",
+}
+
+func tokenizePredicate(predicate string) ([]predicateToken, error) {
+	runes := []rune(predicate)
+	var tokens []predicateToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case r == '"' || r == '\'':
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &predicateSyntaxError{Column: start + 1, Message: "the string starting here is not terminated"}
+			}
+			i++
+			tokens = append(tokens, predicateToken{kind: predicateString, text: string(runes[start:i]), column: start + 1})
+
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || (runes[i] == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]))) {
+				i++
+			}
+			tokens = append(tokens, predicateToken{kind: predicateNumber, text: string(runes[start:i]), column: start + 1})
+
+		case r == '$' || r == '@' || r == '#' || unicode.IsLetter(r) || r == '_':
+			i++
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			kind := predicateIdentifier
+			switch r {
+			case '$':
+				kind = predicateVariable
+			case '@', '#':
+				// # escapes reserved words used as keys.
+				kind = predicateKey
+			}
+			if kind != predicateIdentifier && i == start+1 {
+				return nil, &predicateSyntaxError{Column: start + 1, Message: fmt.Sprintf("%q must be followed by a name", string(r))}
+			}
+			// @status(...) and @property(...) take the name of a status item
+			// or property, which may contain dashes, as their argument.
+			if r == '@' && i < len(runes) && runes[i] == '(' {
+				open := i
+				i++
+				for i < len(runes) && runes[i] != ')' {
+					if !isPredicateNameRune(runes[i]) {
+						return nil, &predicateSyntaxError{Column: i + 1, Message: fmt.Sprintf("%q is not allowed in the argument of %s", string(runes[i]), string(runes[start:open]))}
+					}
+					i++
+				}
+				if i >= len(runes) {
+					return nil, &predicateSyntaxError{Column: open + 1, Message: fmt.Sprintf("the ( of %s is not closed", string(runes[start:open]))}
+				}
+				if i == open+1 {
+					return nil, &predicateSyntaxError{Column: i + 1, Message: fmt.Sprintf("%s needs a name between the parentheses", string(runes[start:open]))}
+				}
+				i++
+			}
+			tokens = append(tokens, predicateToken{kind: kind, text: string(runes[start:i]), column: start + 1})
+
+		default:
+			operator := ""
+			for _, candidate := range predicateOperators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, &predicateSyntaxError{Column: start + 1, Message: fmt.Sprintf("unexpected character %q", string(r))}
+			}
+			i += len([]rune(operator))
+			tokens = append(tokens, predicateToken{kind: predicatePunctuation, text: operator, column: start + 1})
+		}
+	}
+	return append(tokens, predicateToken{kind: predicateEnd, column: len(runes) + 1}), nil
+}
+
+func isPredicateNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_'
+}
+
+// predicateParser is a recursive descent parser of the predicate format
+// string syntax. It only checks the syntax, the predicate is not evaluated.
+type predicateParser struct {
+	tokens   []predicateToken
+	position int
+}
+
+// parsePredicate checks the syntax of a predicate format string.
+func parsePredicate(predicate string) error {
+	tokens, err := tokenizePredicate(predicate)
+	if err != nil {
+		return err
+	}
+	p := &predicateParser{tokens: tokens}
+	if err := p.predicate(); err != nil {
+		return err
+	}
+	if token := p.peek(); token.kind != predicateEnd {
+		return p.unexpected(token, "AND, OR or the end of the predicate")
+	}
+	return nil
+}
+
+func (p *predicateParser) peek() predicateToken {
+	return p.tokens[p.position]
+}
+
+func (p *predicateParser) next() predicateToken {
+	token := p.tokens[p.position]
+	if token.kind != predicateEnd {
+		p.position++
+	}
+	return token
+}
+
+func (p *predicateParser) unexpected(token predicateToken, expected string) error {
+	return &predicateSyntaxError{Column: token.column, Message: fmt.Sprintf("expected %s, got %s", expected, token)}
+}
+
+func (p *predicateParser) predicate() error {
+	if err := p.conjunction(); err != nil {
+		return err
+	}
+	for p.peek().is("OR", "||") {
+		p.next()
+		if err := p.conjunction(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *predicateParser) conjunction() error {
+	if err := p.negation(); err != nil {
+		return err
+	}
+	for p.peek().is("AND", "&&") {
+		p.next()
+		if err := p.negation(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *predicateParser) negation() error {
+	if p.peek().is("NOT", "!") {
+		p.next()
+		return p.negation()
+	}
+	return p.primary()
+}
+
+func (p *predicateParser) primary() error {
+	token := p.peek()
+	if token.is("TRUEPREDICATE", "FALSEPREDICATE") {
+		p.next()
+		return nil
+	}
+	if token.is("(") {
+		// A parenthesis either groups predicates or starts an expression
+		// like (a + b) > 1, try the predicate first.
+		start := p.position
+		p.next()
+		err := p.predicate()
+		if err == nil {
+			if closing := p.peek(); closing.is(")") {
+				p.next()
+				return nil
+			} else if !closing.is(predicateComparisons...) {
+				return p.unexpected(closing, fmt.Sprintf(") to close the ( at column %d", token.column))
+			}
+		}
+		p.position = start
+		if comparisonErr := p.comparison(); comparisonErr != nil {
+			if err != nil {
+				return err
+			}
+			return comparisonErr
+		}
+		return nil
+	}
+	return p.comparison()
+}
+
+func (p *predicateParser) comparison() error {
+	if p.peek().is("ANY", "ALL", "NONE", "SOME") {
+		p.next()
+	}
+	if err := p.expression(); err != nil {
+		return err
+	}
+
+	operator := p.peek()
+	if !operator.is(predicateComparisons...) {
+		return p.unexpected(operator, "a comparison operator such as ==, IN or BEGINSWITH")
+	}
+	p.next()
+	if err := p.options(); err != nil {
+		return err
+	}
+	return p.expression()
+}
+
+// options reads the [c], [d] or [cd] modifiers of string comparisons.
+func (p *predicateParser) options() error {
+	if !p.peek().is("[") {
+		return nil
+	}
+	open := p.next()
+	option := p.next()
+	if option.kind != predicateIdentifier || strings.Trim(strings.ToLower(option.text), "cdnl") != "" {
+		return p.unexpected(option, "comparison options such as c, d or cd")
+	}
+	if closing := p.next(); !closing.is("]") {
+		return p.unexpected(closing, fmt.Sprintf("] to close the [ at column %d", open.column))
+	}
+	return nil
+}
+
+func (p *predicateParser) expression() error {
+	if err := p.term(); err != nil {
+		return err
+	}
+	for p.peek().is("+", "-") {
+		p.next()
+		if err := p.term(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *predicateParser) term() error {
+	if err := p.factor(); err != nil {
+		return err
+	}
+	for p.peek().is("*", "/") {
+		p.next()
+		if err := p.factor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *predicateParser) factor() error {
+	if err := p.unary(); err != nil {
+		return err
+	}
+	for p.peek().is("**") {
+		p.next()
+		if err := p.unary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *predicateParser) unary() error {
+	if p.peek().is("-") {
+		p.next()
+		return p.unary()
+	}
+	if err := p.value(); err != nil {
+		return err
+	}
+	return p.keyPath()
+}
+
+func (p *predicateParser) value() error {
+	token := p.next()
+	switch {
+	case token.kind == predicateString, token.kind == predicateNumber, token.kind == predicateVariable, token.kind == predicateKey:
+		return nil
+
+	case token.is("{"):
+		if p.peek().is("}") {
+			p.next()
+			return nil
+		}
+		for {
+			if err := p.expression(); err != nil {
+				return err
+			}
+			separator := p.next()
+			if separator.is("}") {
+				return nil
+			}
+			if !separator.is(",") {
+				return p.unexpected(separator, fmt.Sprintf(", or } to close the { at column %d", token.column))
+			}
+		}
+
+	case token.is("("):
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if closing := p.next(); !closing.is(")") {
+			return p.unexpected(closing, fmt.Sprintf(") to close the ( at column %d", token.column))
+		}
+		return nil
+
+	case token.kind == predicateIdentifier && !token.is(predicateReserved...):
+		// Functions such as now() take their arguments in parentheses. The
+		// third argument of SUBQUERY(collection, $variable, predicate) is a
+		// predicate.
+		if p.peek().is("(") {
+			open := p.next()
+			if p.peek().is(")") {
+				p.next()
+				return nil
+			}
+			for argument := 0; ; argument++ {
+				parse := p.expression
+				if token.is("SUBQUERY") && argument == 2 {
+					parse = p.predicate
+				}
+				if err := parse(); err != nil {
+					return err
+				}
+				separator := p.next()
+				if separator.is(")") {
+					return nil
+				}
+				if !separator.is(",") {
+					return p.unexpected(separator, fmt.Sprintf(", or ) to close the ( at column %d", open.column))
+				}
+			}
+		}
+		return nil
+	}
+	return p.unexpected(token, "a value, key path or @status(...)")
+}
+
+// keyPath reads the .key and [index] suffixes following a value.
+func (p *predicateParser) keyPath() error {
+	for {
+		switch token := p.peek(); {
+		case token.is("."):
+			p.next()
+			key := p.next()
+			if key.kind != predicateIdentifier && key.kind != predicateKey {
+				return p.unexpected(key, "a key after .")
+			}
+		case token.is("["):
+			p.next()
+			if p.peek().is("FIRST", "LAST", "SIZE") {
+				p.next()
+			} else if err := p.expression(); err != nil {
+				return err
+			}
+			if closing := p.next(); !closing.is("]") {
+				return p.unexpected(closing, fmt.Sprintf("] to close the [ at column %d", token.column))
+			}
+		default:
+			return nil
+		}
+	}
+}
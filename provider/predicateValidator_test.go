@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParsePredicateAcceptsValidPredicates(t *testing.T) {
+	for _, predicate := range []string{
+		`@status(device.model.family) == "iPhone"`,
+		`@status(device.operating-system.family) == 'macOS' AND @status(device.operating-system.version) >= '14.0'`,
+		`@status(device.model.identifier) BEGINSWITH[c] "iPad" || NOT (@status(device.model.family) IN {"iPhone", "Watch"})`,
+		`@property(department) ENDSWITH "ing" OR @status(device.operating-system.marketing-name) CONTAINS[cd] "sonoma"`,
+		`@status(management.client-capabilities.supported-versions) != nil`,
+		`(@status(device.operating-system.version) > "13") AND !(@status(device.model.family) = "Mac")`,
+		`ANY @status(device.power.battery-health.batteries) LIKE "*Good*"`,
+		`@status(device.operating-system.build-version) BETWEEN {"21A1", "21Z9"}`,
+		`(1 + 2) * 3 >= $limit AND items[FIRST].name MATCHES "^a.*"`,
+		`TRUEPREDICATE`,
+		`SUBQUERY(items, $x, $x.a > 1 AND $x.b == "c").@count > 0`,
+	} {
+		if err := parsePredicate(predicate); err != nil {
+			t.Errorf("%s: unexpected error %s", predicate, err)
+		}
+	}
+}
+
+func TestParsePredicateReportsColumns(t *testing.T) {
+	tests := map[string]struct {
+		predicate string
+		expect    string
+	}{
+		"number only": {
+			predicate: `1234`,
+			expect:    "expected a comparison operator such as ==, IN or BEGINSWITH, got the end of the predicate at column 5",
+		},
+		"missing operator": {
+			predicate: `@status(device.model.family) "iPhone"`,
+			expect:    `expected a comparison operator such as ==, IN or BEGINSWITH, got "\"iPhone\"" at column 30`,
+		},
+		"missing value": {
+			predicate: `@status(device.model.family) == AND`,
+			expect:    `expected a value, key path or @status(...), got "AND" at column 33`,
+		},
+		"unterminated string": {
+			predicate: `@status(device.model.family) == "iPhone`,
+			expect:    "the string starting here is not terminated at column 33",
+		},
+		"unclosed parenthesis": {
+			predicate: `(@status(device.model.family) == "iPhone"`,
+			expect:    "expected ) to close the ( at column 1, got the end of the predicate at column 42",
+		},
+		"unclosed status": {
+			predicate: `@status(device.model.family == "iPhone"`,
+			expect:    `" " is not allowed in the argument of @status at column 28`,
+		},
+		"trailing tokens": {
+			predicate: `@status(device.model.family) == "iPhone" "iPad"`,
+			expect:    `expected AND, OR or the end of the predicate, got "\"iPad\"" at column 42`,
+		},
+		"unknown options": {
+			predicate: `@status(device.model.family) BEGINSWITH[x] "i"`,
+			expect:    `expected comparison options such as c, d or cd, got "x" at column 41`,
+		},
+		"unexpected character": {
+			predicate: `@status(device.model.family) == "iPhone" & TRUEPREDICATE`,
+			expect:    `unexpected character "&" at column 42`,
+		},
+		"unclosed list": {
+			predicate: `@status(device.model.family) IN {"iPhone" "iPad"}`,
+			expect:    `expected , or } to close the { at column 33, got "\"iPad\"" at column 43`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := parsePredicate(test.predicate)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if err.Error() != test.expect {
+				t.Errorf("expected %q, got %q", test.expect, err)
+			}
+		})
+	}
+}
+
+func TestPredicateValidator(t *testing.T) {
+	validate := func(value types.String) []string {
+		resp := &validator.StringResponse{}
+		predicateValidator{}.ValidateString(context.Background(), validator.StringRequest{
+			Path:        path.Root("activation_predicate"),
+			ConfigValue: value,
+		}, resp)
+
+		var details []string
+		for _, diagnostic := range resp.Diagnostics.Errors() {
+			details = append(details, diagnostic.Detail())
+		}
+		return details
+	}
+
+	for _, value := range []types.String{types.StringNull(), types.StringUnknown(), types.StringValue(""), types.StringValue(`@status(device.model.family) == "iPhone"`)} {
+		if details := validate(value); len(details) != 0 {
+			t.Errorf("%s: unexpected errors %v", value, details)
+		}
+	}
+
+	details := validate(types.StringValue(`@status(device.model.family) =`))
+	if len(details) != 1 || !strings.HasPrefix(details[0], "Attribute activation_predicate is not a valid predicate: expected a value") || !strings.HasSuffix(details[0], "at column 31.") {
+		t.Errorf("unexpected errors %v", details)
+	}
+}
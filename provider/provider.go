@@ -2,21 +2,31 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/DavidKrau/simplemdm-go-client"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/action"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces
 var (
-	_ provider.Provider = &simplemdmProvider{}
+	_ provider.Provider              = &simplemdmProvider{}
+	_ provider.ProviderWithFunctions = &simplemdmProvider{}
+	_ provider.ProviderWithActions   = &simplemdmProvider{}
 )
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -34,12 +44,25 @@ type simplemdmProvider struct {
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+
+	// transport replaces the HTTP transport of the SimpleMDM client. It is
+	// only set by the acceptance tests to reach the offline stand-in server.
+	transport http.RoundTripper
 }
 
 // simplemdmProviderModel maps provider schema data to a Go type.
 type simplemdmProviderModel struct {
-	Host   types.String `tfsdk:"host"`
-	APIKey types.String `tfsdk:"apikey"`
+	Host           types.String `tfsdk:"host"`
+	APIKey         types.String `tfsdk:"apikey"`
+	APIKeyFile     types.String `tfsdk:"apikey_file"`
+	APIKeyCommand  types.String `tfsdk:"apikey_command"`
+	Profile        types.String `tfsdk:"profile"`
+	MaxRetries     types.Int64  `tfsdk:"max_retries"`
+	RetryMaxWait   types.String `tfsdk:"retry_max_wait"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+	HTTPTraceFile  types.String `tfsdk:"http_trace_file"`
+	CacheTTL       types.String `tfsdk:"cache_ttl"`
+	MaxConcurrency types.Int64  `tfsdk:"max_concurrency"`
 }
 
 // Metadata returns the provider type name.
@@ -58,7 +81,71 @@ func (p *simplemdmProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 			},
 			"apikey": schema.StringAttribute{
 				Optional:    true,
+				Sensitive:   true,
 				Description: "API key for you instance, can be set as environment variable SIMPLEMDM_APIKEY",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(credentialAttributes("apikey")...),
+				},
+			},
+			"apikey_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path of a file containing the API key, leading and trailing whitespace is ignored.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(credentialAttributes("apikey_file")...),
+				},
+			},
+			"apikey_command": schema.StringAttribute{
+				Optional:    true,
+				Description: "Command run with the system shell which prints the API key to stdout, for example \"op read op://vault/simplemdm/apikey\". The first line of the output is used.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(credentialAttributes("apikey_command")...),
+				},
+			},
+			"profile": schema.StringAttribute{
+				Optional:    true,
+				Description: "Name of the section in the credentials file to take the API key, and optionally the host, from. Can be set as environment variable SIMPLEMDM_PROFILE. The credentials file is ~/.config/simplemdm/credentials unless SIMPLEMDM_CREDENTIALS_FILE points elsewhere.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(credentialAttributes("profile")...),
+				},
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional:    true,
+				Description: "How many times an API call is retried when SimpleMDM throttles the request (HTTP 429) or returns a temporary server error (HTTP 5xx). Server errors are only retried for idempotent calls. Defaults to 4, set to 0 to disable retries.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_max_wait": schema.StringAttribute{
+				Optional:    true,
+				Description: "Longest wait between two attempts of the same API call as a Go duration, for example \"30s\". Waits grow exponentially with jitter and a Retry-After header sent by SimpleMDM is honored up to this limit. Defaults to 30s.",
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:    true,
+				Description: "Timeout of a single attempt of an API call as a Go duration, for example \"1m\". Defaults to 60s.",
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"cache_ttl": schema.StringAttribute{
+				Optional:    true,
+				Description: "How long list responses (profiles, apps, devices, assignment groups and attributes) are shared between resources and data sources as a Go duration, for example \"5m\". Any change the provider makes clears the cache. Defaults to 5m, set to \"0s\" to disable caching. Devices still read the profiles assigned to them from one download of the profile list until the provider changes something.",
+				Validators: []validator.String{
+					durationValidator{allowZero: true},
+				},
+			},
+			"max_concurrency": schema.Int64Attribute{
+				Optional:    true,
+				Description: "How many API calls run in parallel when a resource assigns or removes many objects at once, for example the apps, profiles and devices of an assignment group. Throttling by SimpleMDM pauses all of them. Defaults to 4, set to 1 to make the calls one after another.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"http_trace_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path of a file the provider writes every SimpleMDM API call to in HTTP Archive (HAR) format, useful for troubleshooting and replaying calls against a test server. Every provider run starts a new file, Terraform runs the provider separately for plan and apply. Aliased provider configurations need a file each, a file in use by another one is refused. The API key and secret values are redacted but the file still contains your SimpleMDM data.",
 			},
 		},
 	}
@@ -86,15 +173,31 @@ func (p *simplemdmProvider) Configure(ctx context.Context, req provider.Configur
 		)
 	}
 
-	if config.APIKey.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("apikey"),
-			"Unknown SimpleMDM API key",
-			"The provider cannot create the simplemdm API client as there is an unknown configuration value for the SimpleMDM API key. "+
-				"Either target apply the source of the value first, set the value statically in the configuration, or use the SIMPLEMDM_APIKEY environment variable.",
-		)
+	for _, attribute := range []struct {
+		name  string
+		value types.String
+	}{
+		{"apikey", config.APIKey},
+		{"apikey_file", config.APIKeyFile},
+		{"apikey_command", config.APIKeyCommand},
+		{"profile", config.Profile},
+	} {
+		if attribute.value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attribute.name),
+				"Unknown SimpleMDM API key",
+				"The provider cannot create the simplemdm API client as there is an unknown configuration value for "+attribute.name+". "+
+					"Either target apply the source of the value first, set the value statically in the configuration, or use the SIMPLEMDM_APIKEY environment variable.",
+			)
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	creds, diags := resolveCredentials(ctx, config)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -102,18 +205,18 @@ func (p *simplemdmProvider) Configure(ctx context.Context, req provider.Configur
 	// Default values to environment variables, but override
 	// with Terraform configuration value if set.
 	host := os.Getenv("SIMPLEMDM_HOST")
-	apikey := os.Getenv("SIMPLEMDM_APIKEY")
+	apikey := creds.APIKey
 
 	if !config.Host.IsNull() {
 		host = config.Host.ValueString()
 	}
 
-	if !config.APIKey.IsNull() {
-		apikey = config.APIKey.ValueString()
-	}
-
 	// If any of the expected configurations are missing, return
 	// errors with provider-specific guidance.
+	if host == "" {
+		host = creds.Host
+	}
+
 	if host == "" {
 		host = "a.simplemdm.com"
 	}
@@ -122,8 +225,9 @@ func (p *simplemdmProvider) Configure(ctx context.Context, req provider.Configur
 		resp.Diagnostics.AddAttributeError(
 			path.Root("apikey"),
 			"Missing SimpleMDM API key",
-			"The provider cannot create the SimpleMDM API client as there is a missing or empty value for the SimpleMDM API key. "+
-				"Set the apikey value in the configuration or use the SIMPLEMDM_APIKEY environment variable. "+
+			"The provider cannot create the SimpleMDM API client as there is a missing or empty value for the SimpleMDM API key (source: "+creds.Source+"). "+
+				"Set one of apikey, apikey_file, apikey_command or profile in the configuration, use the SIMPLEMDM_APIKEY or SIMPLEMDM_PROFILE environment variable, "+
+				"or add a [default] section to the credentials file. "+
 				"If either is already set, ensure the value is not empty.",
 		)
 	}
@@ -133,16 +237,81 @@ func (p *simplemdmProvider) Configure(ctx context.Context, req provider.Configur
 	}
 
 	ctx = tflog.SetField(ctx, "simplemdm_host", host)
-	ctx = tflog.SetField(ctx, "simplemdm_apikey", apikey)
+	ctx = tflog.SetField(ctx, "simplemdm_apikey_source", creds.Source)
+	// Never log the API key, mask it wherever it could show up instead.
+	ctx = newHTTPLogContext(ctx, apikey)
 
 	tflog.Debug(ctx, "Creating SimpleMDM client")
 
+	maxRetries := defaultMaxRetries
+	if !config.MaxRetries.IsNull() && !config.MaxRetries.IsUnknown() {
+		maxRetries = int(config.MaxRetries.ValueInt64())
+	}
+
+	retryMaxWait := defaultRetryMaxWait
+	if !config.RetryMaxWait.IsNull() && !config.RetryMaxWait.IsUnknown() {
+		retryMaxWait, _ = time.ParseDuration(config.RetryMaxWait.ValueString())
+	}
+
+	requestTimeout := defaultRequestTimeout
+	if !config.RequestTimeout.IsNull() && !config.RequestTimeout.IsUnknown() {
+		requestTimeout, _ = time.ParseDuration(config.RequestTimeout.ValueString())
+	}
+
+	ctx = tflog.SetField(ctx, "simplemdm_max_retries", maxRetries)
+
 	apiClient := simplemdm.NewClient(host, apikey)
 
-	// Make the SimpleMDM client available during DataSource and Resource
-	// type Configure methods.
-	resp.DataSourceData = apiClient
-	resp.ResourceData = apiClient
+	cacheTTL := defaultCacheTTL
+	if !config.CacheTTL.IsNull() && !config.CacheTTL.IsUnknown() {
+		cacheTTL, _ = time.ParseDuration(config.CacheTTL.ValueString())
+	}
+
+	maxConcurrency := defaultMaxConcurrency
+	if !config.MaxConcurrency.IsNull() && !config.MaxConcurrency.IsUnknown() {
+		maxConcurrency = int(config.MaxConcurrency.ValueInt64())
+	}
+
+	var recorder *harRecorder
+	if !config.HTTPTraceFile.IsNull() && !config.HTTPTraceFile.IsUnknown() && config.HTTPTraceFile.ValueString() != "" {
+		var err error
+		recorder, err = newHARRecorder(config.HTTPTraceFile.ValueString(), p.version)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("http_trace_file"),
+				"Unable to Open HTTP Trace File",
+				"The provider can't write the HTTP trace file: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	// Send all client traffic through the list cache and the retrying
+	// transport, every attempt is logged by the transport below it. The timeout is applied per
+	// attempt by the transport, a client wide timeout would also cover the
+	// waits between retries.
+	base := apiClient.HTTPClient.Transport
+	if p.transport != nil {
+		base = p.transport
+	}
+	base = newLoggingTransport(ctx, base, apikey, recorder)
+	base = newRetryTransport(base, maxRetries, retryMaxWait, requestTimeout)
+	cache := newListCache(ctx, base, cacheTTL)
+	apiClient.HTTPClient.Transport = cache
+	apiClient.HTTPClient.Timeout = 0
+
+	client := &simplemdmClient{
+		Client:         apiClient,
+		apiKey:         apikey,
+		cache:          cache,
+		maxConcurrency: maxConcurrency,
+	}
+
+	// Make the SimpleMDM client available during DataSource, Resource and
+	// Action type Configure methods.
+	resp.DataSourceData = client
+	resp.ResourceData = client
+	resp.ActionData = client
 
 	tflog.Info(ctx, "Configured SimpleMDM client", map[string]any{"success": true})
 
@@ -151,7 +320,14 @@ func (p *simplemdmProvider) Configure(ctx context.Context, req provider.Configur
 // DataSources defines the data sources implemented in the provider.
 func (p *simplemdmProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
-		AppDataSource, AttributeDataSource, CustomProfileDataSource, ProfileDataSource, DeviceDataSource, ScriptDataSource, CustomDeclarationDataSource,
+		AppDataSource, AttributeDataSource, CustomProfileDataSource, ProfileDataSource, DeviceDataSource, DevicesDataSource, ScriptDataSource, CustomDeclarationDataSource,
+	}
+}
+
+// Actions defines the actions implemented in the provider.
+func (p *simplemdmProvider) Actions(_ context.Context) []func() action.Action {
+	return []func() action.Action{
+		DeviceLockAction, DeviceWipeAction, DeviceRestartAction, DeviceShutdownAction, DeviceClearPasscodeAction, DevicePushAppsAction,
 	}
 }
 
@@ -161,3 +337,57 @@ func (p *simplemdmProvider) Resources(_ context.Context) []func() resource.Resou
 		CustomProfileResource, AttributeResource, AssignmentGroupResource, DeviceResource, ScriptResource, ScriptJobResource, AppResource, CustomDeclarationResource,
 	}
 }
+
+// Functions defines the provider-defined functions implemented in the provider.
+func (p *simplemdmProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		MobileconfigFunction,
+		MobileconfigDecodeFunction,
+	}
+}
+
+// credentialAttributes returns the paths of the attributes which set the API
+// key, except the given one. Only one of them may be configured.
+func credentialAttributes(except string) []path.Expression {
+	expressions := []path.Expression{}
+	for _, name := range []string{"apikey", "apikey_file", "apikey_command", "profile"} {
+		if name != except {
+			expressions = append(expressions, path.MatchRoot(name))
+		}
+	}
+	return expressions
+}
+
+// durationValidator checks that a string attribute holds a positive Go
+// duration such as "30s" or "2m".
+type durationValidator struct {
+	// allowZero also accepts a zero duration, for settings which it turns
+	// off.
+	allowZero bool
+}
+
+func (v durationValidator) Description(_ context.Context) string {
+	if v.allowZero {
+		return "value must be a duration such as \"30s\" or \"2m\""
+	}
+	return "value must be a positive duration such as \"30s\" or \"2m\""
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	duration, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err != nil || duration < 0 || (duration == 0 && !v.allowZero) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Duration",
+			fmt.Sprintf("Attribute %s %s, got: %q", req.Path, v.Description(ctx), req.ConfigValue.ValueString()),
+		)
+	}
+}
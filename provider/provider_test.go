@@ -1,6 +1,11 @@
 package provider
 
 import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 )
@@ -17,11 +22,54 @@ provider "simplemdm" {
 )
 
 var (
+	// testAccFake is the offline stand-in server, it is nil when the tests
+	// run against a live SimpleMDM account.
+	testAccFake *fakeSimpleMDM
+
+	// testAccTransport is the HTTP transport used by the provider under test,
+	// it trusts the certificate of the offline stand-in server.
+	testAccTransport http.RoundTripper
+
 	// testAccProtoV6ProviderFactories are used to instantiate a provider during
 	// acceptance testing. The factory function will be invoked for every Terraform
 	// CLI command executed to create a provider server to which the CLI can
 	// reattach.
 	testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
-		"simplemdm": providerserver.NewProtocol6WithError(New("test")()),
+		"simplemdm": func() (tfprotov6.ProviderServer, error) {
+			return providerserver.NewProtocol6WithError(&simplemdmProvider{
+				version:   "test",
+				transport: testAccTransport,
+			})()
+		},
 	}
 )
+
+// TestMain runs the acceptance tests against an offline stand-in for the
+// SimpleMDM API which throttles every fifth request. Set SIMPLEMDM_ACC_LIVE
+// to run them against the account configured with SIMPLEMDM_HOST and
+// SIMPLEMDM_APIKEY instead.
+func TestMain(m *testing.M) {
+	if os.Getenv("SIMPLEMDM_ACC_LIVE") != "" {
+		os.Exit(m.Run())
+	}
+
+	fake := newFakeSimpleMDM()
+	testAccFake = fake
+	// Throttle regularly so every acceptance test also exercises the
+	// retrying transport.
+	fake.throttleEvery = 5
+	testAccTransport = fake.transport()
+	for key, value := range map[string]string{
+		"SIMPLEMDM_HOST":   fake.host(),
+		"SIMPLEMDM_APIKEY": fakeAPIKey,
+	} {
+		if err := os.Setenv(key, value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	code := m.Run()
+	fake.close()
+	os.Exit(code)
+}
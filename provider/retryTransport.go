@@ -0,0 +1,253 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 4
+	defaultRetryMaxWait   = 30 * time.Second
+	defaultRequestTimeout = 60 * time.Second
+
+	// retryBaseWait is the wait before the first retry, it doubles with every
+	// following attempt until it reaches the configured maximum.
+	retryBaseWait = 500 * time.Millisecond
+)
+
+// retryTransport is a http.RoundTripper which retries SimpleMDM API calls
+// which failed because of throttling or a temporary server error.
+//
+// A 429 response is retried for every method because SimpleMDM rejects the
+// request before doing any work. 5xx responses and network errors are only
+// retried for idempotent methods, a POST or PATCH could already have been
+// applied. Waits grow exponentially with jitter and a Retry-After header
+// sent by the server takes precedence, both are capped at maxWait.
+//
+// A throttled request also holds back every other request and retry until its
+// wait is over, so parallel callers don't keep hitting the rate limit.
+type retryTransport struct {
+	base           http.RoundTripper
+	maxRetries     int
+	maxWait        time.Duration
+	requestTimeout time.Duration
+
+	// sleep waits for the given duration or until the context is done, tests
+	// replace it to avoid slowing down.
+	sleep func(context.Context, time.Duration) error
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries int, maxWait, requestTimeout time.Duration) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:           base,
+		maxRetries:     maxRetries,
+		maxWait:        maxWait,
+		requestTimeout: requestTimeout,
+		sleep:          sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body is consumed by every attempt, keep a copy so it can be sent
+	// again.
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// waitedUntil is when the last backoff of this request ended, retries
+	// only wait for the part of a pause which lasts beyond it.
+	var waitedUntil time.Time
+	for attempt := 0; ; attempt++ {
+		if wait := t.pauseRemaining(waitedUntil); wait > 0 {
+			if err := t.sleep(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptReq, cancel, err := t.prepareAttempt(req, body)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.maxRetries || !t.shouldRetry(req, resp, err) {
+			if err != nil || resp == nil {
+				cancel()
+				return resp, err
+			}
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		wait := t.backoff(attempt, resp)
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			t.pause(wait)
+		}
+		if resp != nil {
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		cancel()
+
+		waitedUntil = time.Now().Add(wait)
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pause holds back new requests for d.
+func (t *retryTransport) pause(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+}
+
+// pauseRemaining returns how long an attempt has to wait because a request
+// was throttled, counting from after or from now if that is later.
+func (t *retryTransport) pauseRemaining(after time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pausedUntil.Sub(latest(time.Now(), after))
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// prepareAttempt clones the request with a fresh body and the per attempt
+// timeout applied to its context.
+func (t *retryTransport) prepareAttempt(req *http.Request, body []byte) (*http.Request, context.CancelFunc, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.requestTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+
+	attemptReq := req.Clone(ctx)
+	switch {
+	case req.GetBody != nil:
+		newBody, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		attemptReq.Body = newBody
+	case body != nil:
+		attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return attemptReq, cancel, nil
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isIdempotent(req.Method)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		return isIdempotent(req.Method)
+	}
+	return false
+}
+
+// backoff returns how long to wait before the next attempt.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, t.maxWait)
+		}
+	}
+
+	wait := t.maxWait
+	if attempt < 30 {
+		wait = min(retryBaseWait<<attempt, t.maxWait)
+	}
+	// Equal jitter, wait somewhere between half and the full backoff so
+	// parallel requests don't retry in lockstep.
+	half := wait / 2
+	if half <= 0 {
+		return wait
+	}
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter understands both forms of the Retry-After header, a number
+// of seconds and an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelOnClose releases the per attempt context once the caller is done
+// reading the response body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,297 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRetryTransport returns a retry transport for the stand-in server
+// which records its waits instead of sleeping.
+func newTestRetryTransport(base http.RoundTripper, maxRetries int, waits *[]time.Duration) *retryTransport {
+	transport := newRetryTransport(base, maxRetries, 5*time.Second, 10*time.Second)
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return transport
+}
+
+func fakeRequest(t *testing.T, fake *fakeSimpleMDM, method, path string, form url.Values) *http.Request {
+	t.Helper()
+	var req *http.Request
+	var err error
+	if form != nil {
+		req, err = http.NewRequest(method, fake.server.URL+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = http.NewRequest(method, fake.server.URL+path, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(fakeAPIKey, "")
+	return req
+}
+
+func TestRetryTransportRetriesThrottledCalls(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	fake.throttleEvery = 2
+
+	var waits []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(fake.transport(), 4, &waits)}
+
+	for i := 0; i < 5; i++ {
+		resp, err := client.Do(fakeRequest(t, fake, http.MethodGet, "/api/v1/apps/577575", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: expected status 200, got %d", i, resp.StatusCode)
+		}
+	}
+
+	if fake.requests != 9 {
+		t.Errorf("expected 9 requests to reach the server, got %d", fake.requests)
+	}
+	for _, wait := range waits {
+		if wait != 0 {
+			t.Errorf("expected Retry-After: 0 to be honored, waited %s", wait)
+		}
+	}
+}
+
+func TestRetryTransportReplaysBodyOfThrottledPost(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	fake.faults = []int{http.StatusTooManyRequests, http.StatusTooManyRequests}
+
+	var waits []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(fake.transport(), 4, &waits)}
+
+	form := url.Values{"name": {"retried"}, "default_value": {"value"}}
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodPost, "/api/v1/custom_attributes", form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if attribute := fake.attributes["retried"]; attribute == nil || attribute.DefaultValue != "value" {
+		t.Errorf("expected attribute to be created from the replayed body, got %+v", attribute)
+	}
+	if len(waits) != 2 {
+		t.Errorf("expected 2 retries, got %d", len(waits))
+	}
+}
+
+func TestRetryTransportServerErrors(t *testing.T) {
+	tests := map[string]struct {
+		method       string
+		path         string
+		form         url.Values
+		expectStatus int
+		expectTries  int
+	}{
+		"idempotent call is retried": {
+			method:       http.MethodGet,
+			path:         "/api/v1/scripts/5727",
+			expectStatus: http.StatusOK,
+			expectTries:  3,
+		},
+		"non idempotent call is not retried": {
+			method:       http.MethodPost,
+			path:         "/api/v1/custom_attributes",
+			form:         url.Values{"name": {"not_retried"}},
+			expectStatus: http.StatusBadGateway,
+			expectTries:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeSimpleMDM()
+			defer fake.close()
+			fake.faults = []int{http.StatusBadGateway, http.StatusServiceUnavailable}
+
+			var waits []time.Duration
+			client := &http.Client{Transport: newTestRetryTransport(fake.transport(), 4, &waits)}
+
+			resp, err := client.Do(fakeRequest(t, fake, test.method, test.path, test.form))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.expectStatus {
+				t.Errorf("expected status %d, got %d", test.expectStatus, resp.StatusCode)
+			}
+			if fake.requests != test.expectTries {
+				t.Errorf("expected %d attempts, got %d", test.expectTries, fake.requests)
+			}
+			for _, wait := range waits {
+				if wait <= 0 || wait > 5*time.Second {
+					t.Errorf("expected backoff between 0 and 5s, got %s", wait)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportGivesUpAfterMaxRetries(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+	fake.throttleEvery = 1
+
+	var waits []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(fake.transport(), 2, &waits)}
+
+	resp, err := client.Do(fakeRequest(t, fake, http.MethodGet, "/api/v1/apps/577575", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the last 429 to be returned, got %d", resp.StatusCode)
+	}
+	if fake.requests != 3 {
+		t.Errorf("expected 3 attempts, got %d", fake.requests)
+	}
+}
+
+func TestRetryTransportCapsRetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(http.DefaultTransport, 4, &waits)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(waits) != 1 || waits[0] != 5*time.Second {
+		t.Errorf("expected a single wait capped at 5s, got %v", waits)
+	}
+}
+
+func TestRetryTransportThrottlingHoldsBackOtherRequests(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := &http.Client{Transport: newTestRetryTransport(http.DefaultTransport, 4, &waits)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The test sleep returns at once, so the second request still finds the
+	// pause the first one started.
+	if len(waits) != 2 || waits[0] != 3*time.Second || waits[1] <= 2*time.Second || waits[1] > 3*time.Second {
+		t.Errorf("expected the second request to wait for the throttled first one, got %v", waits)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryTransportRetryWaitsForPauseOfOtherRequest(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	transport := newTestRetryTransport(http.DefaultTransport, 4, &waits)
+	sleep := transport.sleep
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		// Another request is throttled while this one backs off.
+		if len(waits) == 0 {
+			transport.pause(4 * time.Second)
+		}
+		return sleep(ctx, d)
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// The backoff of the first retry is at most 500ms, the retry then waits
+	// for the rest of the pause.
+	if len(waits) != 2 || waits[0] > 500*time.Millisecond || waits[1] < 3*time.Second || waits[1] > 4*time.Second {
+		t.Errorf("expected the retry to wait for the pause, got %v", waits)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		value  string
+		expect time.Duration
+		ok     bool
+	}{
+		"empty":    {value: "", ok: false},
+		"seconds":  {value: "3", expect: 3 * time.Second, ok: true},
+		"negative": {value: "-1", ok: false},
+		"garbage":  {value: "soon", ok: false},
+		"past date": {
+			value:  time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat),
+			expect: 0,
+			ok:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(test.value)
+			if ok != test.ok || wait != test.expect {
+				t.Errorf("expected (%s, %t), got (%s, %t)", test.expect, test.ok, wait, ok)
+			}
+		})
+	}
+}
@@ -2,11 +2,13 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -16,9 +18,10 @@ import (
 )
 
 var (
-	_ resource.Resource                = &attributeResource{}
-	_ resource.ResourceWithConfigure   = &attributeResource{}
-	_ resource.ResourceWithImportState = &attributeResource{}
+	_ resource.Resource                 = &scriptJobResource{}
+	_ resource.ResourceWithConfigure    = &scriptJobResource{}
+	_ resource.ResourceWithImportState  = &scriptJobResource{}
+	_ resource.ResourceWithUpgradeState = &scriptJobResource{}
 )
 
 // scriptJobsResourceModel maps the resource schema data.
@@ -38,7 +41,7 @@ func ScriptJobResource() resource.Resource {
 
 // scriptJobResource is the resource implementation.
 type scriptJobResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -47,7 +50,7 @@ func (r *scriptJobResource) Configure(_ context.Context, req resource.ConfigureR
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -58,6 +61,7 @@ func (r *scriptJobResource) Metadata(_ context.Context, req resource.MetadataReq
 // Schema defines the schema for the resource.
 func (r *scriptJobResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Script resource can be used to manage Scripts Jobs.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -108,10 +112,31 @@ func (r *scriptJobResource) Schema(_ context.Context, _ resource.SchemaRequest,
 	}
 }
 
-// func (r *scriptJobResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-// 	// Retrieve import ID and save to id attribute
-// 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-// }
+// UpgradeState upgrades state saved with older schema versions.
+func (r *scriptJobResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
+// ImportState imports a script job by its ID or by <job ID>:<script ID>.
+// Read looks up the devices of the job and, without a script ID, the
+// script, see importedScriptJob.
+func (r *scriptJobResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	id, scriptID, found := strings.Cut(req.ID, ":")
+	if found && (id == "" || scriptID == "") {
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Import ID %q has to be a script job ID or <job ID>:<script ID>.", req.ID),
+		)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+	if found {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("script_id"), scriptID)...)
+	}
+}
 
 // Create a new resource
 func (r *scriptJobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -136,7 +161,7 @@ func (r *scriptJobResource) Create(ctx context.Context, req resource.CreateReque
 
 	var customAttribute string
 	if !plan.CustomAttribute.IsNull() {
-		customAttribute = plan.CustomAttribute.String()
+		customAttribute = plan.CustomAttribute.ValueString()
 	}
 
 	customAttributeRegex := ""
@@ -153,10 +178,11 @@ func (r *scriptJobResource) Create(ctx context.Context, req resource.CreateReque
 		customAttributeRegex,
 	)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating script job",
-			"Could not create script job, unexpected error: "+err.Error(),
-		)
+			"Could not create script job",
+			err,
+		))
 		return
 	}
 
@@ -186,11 +212,12 @@ func (r *scriptJobResource) Delete(ctx context.Context, req resource.DeleteReque
 
 	// stop script job
 	err := r.client.ScriptCancelJob(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error stopping SimpleMDM script job",
-			"Could not stop script job, unexpected error: "+err.Error(),
-		)
+			"Could not stop script job",
+			err,
+		))
 		return
 	}
 
@@ -208,14 +235,15 @@ func (r *scriptJobResource) Read(ctx context.Context, req resource.ReadRequest,
 	// Call API to get the script job
 	scriptJob, err := r.client.ScriptJobGet(state.ID.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM Script Job",
-			"Could not read SimpleMDM Script Job "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM Script Job "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 	// resp.Diagnostics.AddError(
@@ -225,13 +253,21 @@ func (r *scriptJobResource) Read(ctx context.Context, req resource.ReadRequest,
 	// Update fields returned by the API
 	//state.ScriptId = types.StringValue(scriptJob.Data.Attributes.ScriptName)
 	state.ID = types.StringValue(strconv.Itoa(scriptJob.Data.ID))
-	if scriptJob.Data.Attributes.CustomAttributeRegex != "" {
+	if scriptJob.Data.Relationships.CustomAttribute.Data.ID != "" {
 		state.CustomAttribute = types.StringValue(scriptJob.Data.Relationships.CustomAttribute.Data.ID)
 	}
 	if scriptJob.Data.Attributes.CustomAttributeRegex != "" {
 		state.CustomAttributeRegex = types.StringValue(scriptJob.Data.Attributes.CustomAttributeRegex)
 	}
 
+	// Imported jobs only have an ID and maybe the script ID
+	if state.DeviceIds.IsNull() {
+		resp.Diagnostics.Append(r.importedScriptJob(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -240,7 +276,76 @@ func (r *scriptJobResource) Read(ctx context.Context, req resource.ReadRequest,
 	}
 }
 
+// importedScriptJob fills the arguments of an imported script job. The API
+// doesn't return the script ID, so unless it was imported with the job the
+// script is found by the name and the content the job ran. Without exactly
+// one such script, for example because it was edited or deleted since,
+// script_id stays null for the configuration to set. SimpleMDM resolves assignment groups to their devices
+// when the job is created, imported jobs run on a list of devices.
+func (r *scriptJobResource) importedScriptJob(ctx context.Context, state *scriptJobResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var job struct {
+		Data apiScriptJob `json:"data"`
+	}
+	if err := r.client.apiGet(ctx, "script_jobs/"+state.ID.ValueString(), nil, &job); err != nil {
+		diags.Append(apiErrorDiagnostic(
+			"Error Reading SimpleMDM Script Job",
+			"Could not read SimpleMDM Script Job "+state.ID.ValueString(),
+			err,
+		))
+		return diags
+	}
+
+	if state.ScriptId.IsNull() {
+		scripts, err := listAll[apiScript](ctx, r.client, "scripts", nil)
+		if err != nil {
+			diags.Append(apiErrorDiagnostic(
+				"Error Reading SimpleMDM Scripts",
+				"Could not read the scripts of SimpleMDM",
+				err,
+			))
+			return diags
+		}
+		var scriptIDs []string
+		for _, script := range scripts {
+			if script.Attributes.Name == job.Data.Attributes.ScriptName && script.Attributes.Content == job.Data.Attributes.Content {
+				scriptIDs = append(scriptIDs, strconv.Itoa(script.ID))
+			}
+		}
+		if len(scriptIDs) == 1 {
+			state.ScriptId = types.StringValue(scriptIDs[0])
+		} else {
+			diags.AddWarning(
+				"Script Of Imported Script Job Not Found",
+				fmt.Sprintf("Script job %s ran the script %q, %d scripts in SimpleMDM have its name and content. Set script_id in the configuration of the job, applying it only saves the script ID to the state. Importing the job as %s:<script ID> sets it right away.", state.ID.ValueString(), job.Data.Attributes.ScriptName, len(scriptIDs), state.ID.ValueString()),
+			)
+		}
+	}
+
+	deviceIDs := []string{}
+	for _, device := range job.Data.Relationships.Device.Data {
+		deviceIDs = append(deviceIDs, strconv.Itoa(device.ID))
+	}
+	state.DeviceIds = types.SetValueMust(types.StringType, stringSliceToAttrValues(deviceIDs))
+	state.AssignmentGroupIds = types.SetValueMust(types.StringType, []attr.Value{})
+
+	return diags
+}
+
 func (r *scriptJobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Jobs imported without finding their script get the script ID from
+	// the configuration, everything else replaces the job.
+	var scriptID types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("script_id"), &scriptID)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if scriptID.IsNull() {
+		resp.State.Raw = req.Plan.Raw
+		return
+	}
+
 	// Force the recreation by seeing an appropriate error
 	resp.Diagnostics.AddError(
 		"Update Not Supported",
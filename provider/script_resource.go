@@ -3,9 +3,7 @@ package provider
 import (
 	"context"
 	"strconv"
-	"strings"
 
-	"github.com/DavidKrau/simplemdm-go-client"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -17,9 +15,10 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &scriptResource{}
-	_ resource.ResourceWithConfigure   = &scriptResource{}
-	_ resource.ResourceWithImportState = &scriptResource{}
+	_ resource.Resource                 = &scriptResource{}
+	_ resource.ResourceWithConfigure    = &scriptResource{}
+	_ resource.ResourceWithImportState  = &scriptResource{}
+	_ resource.ResourceWithUpgradeState = &scriptResource{}
 )
 
 // scriptResourceModel maps the resource schema data.
@@ -39,7 +38,7 @@ func ScriptResource() resource.Resource {
 
 // scriptResource is the resource implementation.
 type scriptResource struct {
-	client *simplemdm.Client
+	client *simplemdmClient
 }
 
 // Configure adds the provider configured client to the resource.
@@ -48,7 +47,7 @@ func (r *scriptResource) Configure(_ context.Context, req resource.ConfigureRequ
 		return
 	}
 
-	r.client = req.ProviderData.(*simplemdm.Client)
+	r.client = req.ProviderData.(*simplemdmClient)
 }
 
 // Metadata returns the resource type name.
@@ -59,6 +58,7 @@ func (r *scriptResource) Metadata(_ context.Context, req resource.MetadataReques
 // Schema defines the schema for the resource.
 func (r *scriptResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Script resource can be used to manage Scripts.",
 		Attributes: map[string]schema.Attribute{
 			"name": schema.StringAttribute{
@@ -99,6 +99,14 @@ func (r *scriptResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 	}
 }
 
+// UpgradeState upgrades state saved with older schema versions.
+func (r *scriptResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return stateUpgraders(ctx, r,
+		// 0 to 1: unversioned releases saved the same attributes.
+		nil,
+	)
+}
+
 func (r *scriptResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Retrieve import ID and save to id attribute
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
@@ -117,10 +125,11 @@ func (r *scriptResource) Create(ctx context.Context, req resource.CreateRequest,
 	// Generate API request body from plan
 	script, err := r.client.ScriptCreate(plan.Name.ValueString(), plan.VariableSupport.ValueBool(), plan.ScriptFile.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error creating script",
-			"Could not create script, unexpected error: "+err.Error(),
-		)
+			"Could not create script",
+			err,
+		))
 		return
 	}
 
@@ -150,14 +159,15 @@ func (r *scriptResource) Read(ctx context.Context, req resource.ReadRequest, res
 	// Get script values from SimpleMDM
 	script, err := r.client.ScriptGet(state.ID.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if isNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Reading SimpleMDM Script",
-			"Could not read SimpleMDM Script "+state.ID.ValueString()+": "+err.Error(),
-		)
+			"Could not read SimpleMDM Script "+state.ID.ValueString(),
+			err,
+		))
 		return
 	}
 
@@ -188,10 +198,11 @@ func (r *scriptResource) Update(ctx context.Context, req resource.UpdateRequest,
 	// Generate API request body from plan
 	script, err := r.client.ScriptUpdate(plan.Name.ValueString(), plan.VariableSupport.ValueBool(), plan.ScriptFile.ValueString(), plan.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error updating script",
-			"Could not update script, unexpected error: "+err.Error(),
-		)
+			"Could not update script",
+			err,
+		))
 		return
 	}
 
@@ -214,11 +225,12 @@ func (r *scriptResource) Delete(ctx context.Context, req resource.DeleteRequest,
 
 	// Delete existing script
 	err := r.client.ScriptDelete(state.ID.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.Append(apiErrorDiagnostic(
 			"Error Deleting SimpleMDM script",
-			"Could not delete script, unexpected error: "+err.Error(),
-		)
+			"Could not delete script",
+			err,
+		))
 		return
 	}
 }
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// stateEdit changes saved state, decoded from the JSON Terraform keeps, from
+// one schema version to the next. Numbers are json.Number.
+type stateEdit func(state map[string]any) error
+
+// stateUpgraders returns the state upgraders of r. edits[v] turns state of
+// schema version v into version v+1, so there is one edit per version before
+// the current one; nil edits change nothing. The upgrader of a version
+// applies all edits following it, state of any older version is upgraded to
+// the current schema in one step. Attributes the current schema doesn't have
+// anymore are dropped, the ones it added are null unless an edit sets them.
+func stateUpgraders(ctx context.Context, r resource.Resource, edits ...stateEdit) map[int64]resource.StateUpgrader {
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	current := schemaResp.Schema
+
+	upgraders := make(map[int64]resource.StateUpgrader, len(edits))
+	for version := range edits {
+		upgraders[int64(version)] = resource.StateUpgrader{
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				if req.RawState == nil {
+					resp.Diagnostics.AddError("Unable to Upgrade Resource State", "The saved state is missing. Please report this to the provider developers.")
+					return
+				}
+				value, err := upgradeState(req.RawState.JSON, edits[version:], current.Type().TerraformType(ctx))
+				if err != nil {
+					resp.Diagnostics.AddError(
+						"Unable to Upgrade Resource State",
+						fmt.Sprintf("Upgrading the state saved with schema version %d to version %d failed: %s", version, current.Version, err),
+					)
+					return
+				}
+				resp.State = tfsdk.State{Schema: current, Raw: value}
+			},
+		}
+	}
+	return upgraders
+}
+
+// upgradeState applies edits to the state in raw and reads the result with
+// the current schema type.
+func upgradeState(raw []byte, edits []stateEdit, schemaType tftypes.Type) (tftypes.Value, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var state map[string]any
+	if err := decoder.Decode(&state); err != nil {
+		return tftypes.Value{}, err
+	}
+	if state == nil {
+		return tftypes.Value{}, fmt.Errorf("the state is not an object")
+	}
+
+	for _, edit := range edits {
+		if edit == nil {
+			continue
+		}
+		if err := edit(state); err != nil {
+			return tftypes.Value{}, err
+		}
+	}
+
+	upgraded, err := json.Marshal(state)
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+	rawState := &tfprotov6.RawState{JSON: upgraded}
+	return rawState.UnmarshalWithOpts(schemaType, tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+}
+
+// addStateAttribute returns an edit setting an attribute a schema version
+// added to value, unless the state already holds one.
+func addStateAttribute(name string, value any) stateEdit {
+	return func(state map[string]any) error {
+		if state[name] == nil {
+			state[name] = value
+		}
+		return nil
+	}
+}
+
+// stateStringToNumber returns an edit converting an attribute saved as a
+// string to the number it holds, empty strings become null.
+func stateStringToNumber(name string) stateEdit {
+	return func(state map[string]any) error {
+		text, ok := state[name].(string)
+		if !ok {
+			return nil
+		}
+		if strings.TrimSpace(text) == "" {
+			state[name] = nil
+			return nil
+		}
+		number, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s %q is not a whole number", name, text)
+		}
+		state[name] = number
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// TestStateUpgradeFixtures upgrades the state fixtures of every older schema
+// version of each resource, testfiles/state/<type>_v<version>.json, and
+// compares the result with the fixture of the current version.
+func TestStateUpgradeFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	for _, newResource := range New("test")().Resources(ctx) {
+		r := newResource()
+		metadataResp := &resource.MetadataResponse{}
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "simplemdm"}, metadataResp)
+		name := strings.TrimPrefix(metadataResp.TypeName, "simplemdm_")
+
+		t.Run(name, func(t *testing.T) {
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			current := schemaResp.Schema
+			schemaType := current.Type().TerraformType(ctx)
+
+			withUpgrade, ok := r.(resource.ResourceWithUpgradeState)
+			if !ok {
+				t.Fatalf("expected %s to upgrade state", name)
+			}
+			upgraders := withUpgrade.UpgradeState(ctx)
+			if int64(len(upgraders)) != current.Version {
+				t.Fatalf("expected an upgrader for each of the %d versions before %d, got %d", current.Version, current.Version, len(upgraders))
+			}
+
+			expected, err := os.ReadFile(fmt.Sprintf("testfiles/state/%s_v%d.json", name, current.Version))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedValue, err := (&tfprotov6.RawState{JSON: expected}).Unmarshal(schemaType)
+			if err != nil {
+				t.Fatalf("the fixture of version %d doesn't match the schema: %s", current.Version, err)
+			}
+
+			for version := range current.Version {
+				upgrader, found := upgraders[version]
+				if !found {
+					t.Fatalf("expected an upgrader of version %d", version)
+				}
+				raw, err := os.ReadFile(fmt.Sprintf("testfiles/state/%s_v%d.json", name, version))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				resp := &resource.UpgradeStateResponse{}
+				upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: raw}}, resp)
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("upgrading version %d: %v", version, resp.Diagnostics)
+				}
+				if !resp.State.Raw.Equal(expectedValue) {
+					t.Errorf("upgrading version %d:\nexpected %s\ngot      %s", version, expectedValue, resp.State.Raw)
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeStateRejectsInvalidState(t *testing.T) {
+	ctx := context.Background()
+	upgrader := (&deviceResource{}).UpgradeState(ctx)[0]
+
+	for _, raw := range []string{`[]`, `{"attributes": ["sales"]}`, `{"profiles": "212747"}`} {
+		resp := &resource.UpgradeStateResponse{}
+		upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(raw)}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestUpgradeStateConvertsPriority(t *testing.T) {
+	ctx := context.Background()
+	upgrader := (&assignment_groupResource{}).UpgradeState(ctx)[1]
+
+	for raw, expect := range map[string]types.Int64{
+		`{"priority": "05"}`: types.Int64Value(5),
+		`{"priority": ""}`:   types.Int64Null(),
+		`{"priority": 7}`:    types.Int64Value(7),
+	} {
+		resp := &resource.UpgradeStateResponse{}
+		upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(raw)}}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%s: %v", raw, resp.Diagnostics)
+		}
+		var priority types.Int64
+		resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("priority"), &priority)...)
+		if !priority.Equal(expect) {
+			t.Errorf("%s: expected priority %s, got %s", raw, expect, priority)
+		}
+	}
+
+	resp := &resource.UpgradeStateResponse{}
+	upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(`{"priority": "high"}`)}}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected an error for a priority which isn't a number")
+	}
+}
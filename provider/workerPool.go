@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// defaultMaxConcurrency is how many API calls of one fan-out operation, such
+// as assigning the devices of a group, run at the same time.
+const defaultMaxConcurrency = 4
+
+// taskFailure is the error a task returned for one object ID.
+type taskFailure struct {
+	ID  string
+	Err error
+}
+
+// runConcurrently calls task once for every ID with at most limit calls in
+// flight. It doesn't stop at the first failure, every ID is attempted and
+// the failures are returned in the order of ids. IDs not started before ctx
+// is done fail with the context error.
+//
+// Calls for the same ID are never split between workers, so a task which
+// needs several API calls in a fixed order, like removing and re-adding an
+// app, makes them in that order. Throttling is left to the transport, which
+// holds back every worker once SimpleMDM asks to slow down.
+func runConcurrently(ctx context.Context, limit int, ids []string, task func(id string) error) []taskFailure {
+	if limit < 1 {
+		limit = 1
+	}
+	limit = min(limit, len(ids))
+
+	errs := make([]error, len(ids))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = task(ids[i])
+			}
+		}()
+	}
+	for i := range ids {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failures := []taskFailure{}
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, taskFailure{ID: ids[i], Err: err})
+		}
+	}
+	return failures
+}
+
+// forEach runs task for every ID with the concurrency configured for the
+// provider, see runConcurrently.
+func (c *simplemdmClient) forEach(ctx context.Context, ids []string, task func(id string) error) []taskFailure {
+	return runConcurrently(ctx, c.maxConcurrency, ids, task)
+}
+
+// failedIDs returns the IDs of failures.
+func failedIDs(failures []taskFailure) []string {
+	ids := []string{}
+	for _, failure := range failures {
+		ids = append(ids, failure.ID)
+	}
+	return ids
+}
+
+// taskFailuresDiagnostic reports all failures of a fan-out operation in a
+// single diagnostic which names every failed ID. A single failure is
+// reported like any other API error.
+func taskFailuresDiagnostic(summary, detail string, total int, failures []taskFailure) diag.Diagnostic {
+	if len(failures) == 1 {
+		return apiErrorDiagnostic(summary, detail+" (ID "+failures[0].ID+")", failures[0].Err)
+	}
+
+	lines := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		lines = append(lines, fmt.Sprintf("  - %s: %s", failure.ID, apiErrorMessage(failure.Err)))
+	}
+	return diag.NewErrorDiagnostic(
+		summary,
+		fmt.Sprintf("%s, %d of %d failed (IDs %s):\n%s", detail, len(failures), total, strings.Join(failedIDs(failures), ", "), strings.Join(lines, "\n")),
+	)
+}
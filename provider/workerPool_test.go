@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunConcurrentlyLimitsParallelism(t *testing.T) {
+	ids := []string{}
+	for i := 0; i < 20; i++ {
+		ids = append(ids, string(rune('a'+i)))
+	}
+
+	var running, peak, calls atomic.Int32
+	failures := runConcurrently(context.Background(), 3, ids, func(string) error {
+		calls.Add(1)
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	if len(failures) != 0 {
+		t.Errorf("expected no failures, got %v", failures)
+	}
+	if calls.Load() != 20 {
+		t.Errorf("expected every ID to be processed once, got %d calls", calls.Load())
+	}
+	if peak.Load() > 3 || peak.Load() < 2 {
+		t.Errorf("expected up to 3 calls in flight, got %d", peak.Load())
+	}
+}
+
+func TestRunConcurrentlyCollectsFailures(t *testing.T) {
+	ids := []string{"1", "2", "3", "4", "5", "6"}
+	failures := runConcurrently(context.Background(), 4, ids, func(id string) error {
+		if id == "2" || id == "5" {
+			return errors.New("failed " + id)
+		}
+		return nil
+	})
+
+	if len(failures) != 2 || failures[0].ID != "2" || failures[1].ID != "5" {
+		t.Fatalf("expected failures for 2 and 5 in order, got %v", failures)
+	}
+	if failures[1].Err.Error() != "failed 5" {
+		t.Errorf("expected the error of the task, got %v", failures[1].Err)
+	}
+}
+
+func TestRunConcurrentlyStopsStartingTasksWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	failures := runConcurrently(ctx, 1, []string{"1", "2", "3"}, func(string) error {
+		calls.Add(1)
+		cancel()
+		return nil
+	})
+
+	if calls.Load() != 1 {
+		t.Errorf("expected only the first task to run, got %d", calls.Load())
+	}
+	if len(failures) != 2 || !errors.Is(failures[0].Err, context.Canceled) {
+		t.Errorf("expected the remaining IDs to fail with the context error, got %v", failures)
+	}
+}
+
+func TestAssignmentGroupUpdateObjectsReportsFailedIDs(t *testing.T) {
+	fake := newFakeSimpleMDM()
+	defer fake.close()
+
+	r := &assignment_groupResource{client: fake.client()}
+
+	diags, unassigned := r.updateObjects(context.Background(), "140188", "devices", []string{"1601809", "404", "1601810", "405"}, nil)
+
+	if len(diags) != 1 {
+		t.Fatalf("expected a single diagnostic for all failures, got %v", diags)
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, "2 of 4 failed (IDs 404, 405)") {
+		t.Errorf("expected the failed IDs in the diagnostic, got %q", detail)
+	}
+	if len(unassigned) != 2 || unassigned[0] != "404" || unassigned[1] != "405" {
+		t.Errorf("expected the unassigned IDs, got %v", unassigned)
+	}
+	devices := fake.groups[140188].Devices
+	if !devices[1601809] || !devices[1601810] {
+		t.Errorf("expected the other devices to be assigned, got %v", devices)
+	}
+}